@@ -0,0 +1,34 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+// Attrs is a set of boolean item attributes.  The meaning of each bit is
+// defined by the language being lexed, for example
+//
+//	const (
+//		AttrRaw lexer.Attrs = 1 << iota // raw string literal
+//		AttrContextual                  // contextual keyword
+//	)
+type Attrs uint64
+
+// Has returns true if every attribute in b is set in a.
+func (a Attrs) Has(b Attrs) bool {
+	return a&b == b
+}
+
+// Has returns true if i has every attribute in a.
+func (i *Item) Has(a Attrs) bool {
+	return i.Attrs.Has(a)
+}
+
+// SetMeta sets metadata key to value for the next item emitted by l.
+// Metadata is intended for rare annotations that don't fit in Attrs, such as
+// dialect notes.  Items without metadata have a nil Meta map.
+func (l *Lexer) SetMeta(key, value string) {
+	if l.meta == nil {
+		l.meta = make(map[string]string)
+	}
+	l.meta[key] = value
+}
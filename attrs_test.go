@@ -0,0 +1,36 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+func TestItemAttrs(t *testing.T) {
+	const (
+		attrRaw Attrs = 1 << iota
+		attrMultiline
+	)
+	start := func(l *Lexer) StateFn {
+		l.AcceptRun("`abc\n")
+		l.SetMeta("dialect", "go")
+		l.EmitAttrs(0, attrRaw|attrMultiline)
+		l.AcceptRun("x")
+		l.Emit(1)
+		return nil
+	}
+	l := New(start, "`ab\nc`x")
+	item := l.Next()
+	if !item.Has(attrRaw) || !item.Has(attrRaw|attrMultiline) {
+		t.Errorf("missing attributes %b", item.Attrs)
+	}
+	if item.Meta["dialect"] != "go" {
+		t.Errorf("missing metadata %v", item.Meta)
+	}
+	item = l.Next()
+	if item.Attrs != 0 || item.Meta != nil {
+		t.Errorf("unexpected attributes %b %v", item.Attrs, item.Meta)
+	}
+}
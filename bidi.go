@@ -0,0 +1,64 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// bidiNames maps each Unicode bidirectional control character to its name.
+var bidiNames = map[rune]string{
+	0x061c: "ARABIC LETTER MARK",
+	0x200e: "LEFT-TO-RIGHT MARK",
+	0x200f: "RIGHT-TO-LEFT MARK",
+	0x202a: "LEFT-TO-RIGHT EMBEDDING",
+	0x202b: "RIGHT-TO-LEFT EMBEDDING",
+	0x202c: "POP DIRECTIONAL FORMATTING",
+	0x202d: "LEFT-TO-RIGHT OVERRIDE",
+	0x202e: "RIGHT-TO-LEFT OVERRIDE",
+	0x2066: "LEFT-TO-RIGHT ISOLATE",
+	0x2067: "RIGHT-TO-LEFT ISOLATE",
+	0x2068: "FIRST STRONG ISOLATE",
+	0x2069: "POP DIRECTIONAL ISOLATE",
+}
+
+// bidiControls contains the keys of bidiNames.
+const bidiControls = "\u061c\u200e\u200f\u202a\u202b\u202c\u202d\u202e\u2066\u2067\u2068\u2069"
+
+// IsBidiControl returns true if r is a Unicode bidirectional control
+// character, which can make source text display differently than it is
+// lexed.
+func IsBidiControl(r rune) bool {
+	_, ok := bidiNames[r]
+	return ok
+}
+
+// checkBidi emits a warning for each bidirectional control character in
+// l.input[lo:hi] that has not already been reported.
+func (l *Lexer) checkBidi(lo, hi int) {
+	if lo < l.nextBidi {
+		lo = l.nextBidi
+	}
+	for lo < hi {
+		i := strings.IndexAny(l.input[lo:hi], bidiControls)
+		if i < 0 {
+			break
+		}
+		pos := lo + i
+		r, n := utf8.DecodeRuneInString(l.input[pos:])
+		l.enqueue(&Item{
+			Type:  ItemWarning,
+			Pos:   pos,
+			Value: fmt.Sprintf("bidirectional control character %U (%s)", r, bidiNames[r]),
+			end:   pos + n,
+		})
+		lo = pos + n
+	}
+	if hi > l.nextBidi {
+		l.nextBidi = hi
+	}
+}
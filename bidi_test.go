@@ -0,0 +1,63 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+func TestBidiWarnings(t *testing.T) {
+	const input = "a\u202e\u2066 \"x\u2069\" b"
+	start := func(l *Lexer) StateFn {
+		l.Peek()
+		if l.AcceptString("a") {
+			l.AcceptRun("\u202e\u2066 ")
+			l.Emit(1)
+		}
+		l.AcceptRunNotAny("b")
+		l.Ignore()
+		l.AcceptRun("b")
+		l.Emit(2)
+		return nil
+	}
+	items := Collect(New(start, input, WithBidiWarnings()))
+	want := []struct {
+		typ ItemType
+		pos int
+		msg string
+	}{
+		{ItemWarning, 1, "bidirectional control character U+202E (RIGHT-TO-LEFT OVERRIDE)"},
+		{ItemWarning, 4, "bidirectional control character U+2066 (LEFT-TO-RIGHT ISOLATE)"},
+		{1, 0, ""},
+		{ItemWarning, 10, "bidirectional control character U+2069 (POP DIRECTIONAL ISOLATE)"},
+		{2, 15, ""},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d: %v", len(items), len(want), items)
+	}
+	for i, w := range want {
+		if items[i].Type != w.typ || items[i].Pos != w.pos || w.msg != "" && items[i].Value != w.msg {
+			t.Errorf("item %d: %v at %d %q, want %v at %d %q", i, items[i].Type, items[i].Pos, items[i].Value, w.typ, w.pos, w.msg)
+		}
+	}
+	if n := len(Collect(New(start, input))); n != 2 {
+		t.Errorf("got %d items without WithBidiWarnings", n)
+	}
+	if !IsBidiControl(0x202e) || IsBidiControl('a') {
+		t.Errorf("IsBidiControl")
+	}
+}
+
+func TestBidiBlockComment(t *testing.T) {
+	start := func(l *Lexer) StateFn {
+		l.ScanBlockComment("/*", "*/", false)
+		l.Emit(1)
+		return nil
+	}
+	items := Collect(New(start, "/* \u202e */", WithBidiWarnings()))
+	if len(items) != 2 || items[0].Type != ItemWarning || items[0].Pos != 3 {
+		t.Errorf("items %v", items)
+	}
+}
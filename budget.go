@@ -0,0 +1,49 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import "time"
+
+// A Budget limits the work done by one call to NextBudget.  A zero field
+// imposes no limit.  At least one state function is run by every call that
+// needs an item, so lexing progresses however small the budget.
+type Budget struct {
+	States int           // maximum number of state functions run
+	Time   time.Duration // time after which no further state function is run
+}
+
+// NextBudget is like Next but runs state functions only until b is
+// exhausted, so that an interactive program can lex a large input in slices
+// between other work.  If no item is ready when the budget runs out
+// NextBudget returns false and the next call continues where it stopped.
+// A state function is never interrupted, so the time spent can exceed
+// b.Time by the duration of the last state function run.
+//
+// NextBudget is safe for concurrent use if l was created with the WithSync
+// option.
+func (l *Lexer) NextBudget(b Budget) (i *Item, ready bool) {
+	if l.mu != nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	var deadline time.Time
+	if b.Time > 0 {
+		deadline = time.Now().Add(b.Time)
+	}
+	for n := 0; l.head == len(l.items); n++ {
+		if l.state == nil {
+			if !l.atEOF() {
+				l.finish()
+				return l.eof(), true
+			}
+			break
+		}
+		if n > 0 && (b.States > 0 && n >= b.States || b.Time > 0 && !time.Now().Before(deadline)) {
+			return nil, false
+		}
+		l.runState()
+	}
+	return l.dequeue(), true
+}
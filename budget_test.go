@@ -0,0 +1,62 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+	"time"
+)
+
+// lexSparse emits an item for each 'x' in its input and ignores every other
+// rune in a separate state.
+func lexSparse(l *Lexer) StateFn {
+	switch c, n := l.Advance(); {
+	case n == 0:
+		return nil
+	case c == 'x':
+		l.Emit(0)
+	default:
+		l.Ignore()
+	}
+	return lexSparse
+}
+
+func TestNextBudget(t *testing.T) {
+	l := New(lexSparse, "...x.x")
+	var calls, items int
+	for {
+		calls++
+		item, ready := l.NextBudget(Budget{States: 2})
+		if !ready {
+			continue
+		}
+		if item.Type == ItemEOF {
+			break
+		}
+		items++
+		if item.Value != "x" {
+			t.Errorf("unexpected item %q", item.Value)
+		}
+	}
+	// The six runes and the final state take seven state functions, giving
+	// items after calls 2 and 3 and EOF on call 4.
+	if items != 2 || calls != 4 {
+		t.Errorf("%d items in %d calls", items, calls)
+	}
+}
+
+func TestNextBudgetTime(t *testing.T) {
+	slow := func(l *Lexer) StateFn {
+		time.Sleep(time.Millisecond)
+		return lexSparse(l)
+	}
+	l := New(slow, "....x")
+	if _, ready := l.NextBudget(Budget{Time: time.Nanosecond}); ready {
+		t.Fatalf("item ready after the first state")
+	}
+	if item, ready := l.NextBudget(Budget{}); !ready || item.Value != "x" {
+		t.Errorf("unexpected item %v %v", item, ready)
+	}
+}
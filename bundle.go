@@ -0,0 +1,118 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"unicode/utf8"
+)
+
+// A Bundle is a self-contained reproduction of a lexer's state, suitable for
+// attaching to a bug report as JSON.  A Bundle holds the part of the input
+// around the lexer's position, a snapshot of the lexer, and the tail of the
+// operations logged by its Recorder, which can be re-executed with Replay.
+// Positions in a Bundle are offsets in the original input.
+type Bundle struct {
+	Filename string     `json:"filename,omitempty"`
+	Offset   int        `json:"offset"` // offset of Input in the original input
+	Input    string     `json:"input"`
+	Start    int        `json:"start"` // start of the current lexeme
+	Pos      int        `json:"pos"`
+	State    string     `json:"state"`
+	Stack    []string   `json:"stack,omitempty"` // states saved with PushState
+	Modes    []string   `json:"modes,omitempty"`
+	Queued   []jsonItem `json:"queued,omitempty"` // items not yet consumed by Next
+	Errors   []string   `json:"errors,omitempty"` // errors recorded by WithErrorCollection
+	Ops      []Op       `json:"ops,omitempty"`
+}
+
+// Bundle returns a Bundle for the current state of l with context bytes of
+// input before the current lexeme and after the position of any recorded
+// operation.  Operations are included if l was created with WithRecorder,
+// starting after the last item emitted or ignored before the input in the
+// bundle.
+func (l *Lexer) Bundle(context int) *Bundle {
+	b := &Bundle{
+		Filename: l.src.name,
+		Start:    l.start,
+		Pos:      l.pos,
+		State:    stateName(l.state),
+		Modes:    append([]string(nil), l.modeStack...),
+	}
+	for _, fn := range l.stack {
+		b.Stack = append(b.Stack, stateName(fn))
+	}
+	for _, i := range l.items[l.head:] {
+		b.Queued = append(b.Queued, newJSONItem(i))
+	}
+	for _, err := range l.errs {
+		b.Errors = append(b.Errors, err.Error())
+	}
+	lo := l.start
+	if l.pos < lo {
+		lo = l.pos
+	}
+	lo -= context
+	if lo < 0 {
+		lo = 0
+	}
+	hi := l.pos
+	if l.rec != nil {
+		ops := bundleOps(l.rec.ops, lo)
+		for _, op := range ops {
+			if op.Pos > hi {
+				hi = op.Pos
+			}
+		}
+		b.Ops = ops
+	}
+	hi += context
+	if hi > len(l.input) {
+		hi = len(l.input)
+	}
+	for lo > 0 && !utf8.RuneStart(l.input[lo]) {
+		lo--
+	}
+	for hi < len(l.input) && !utf8.RuneStart(l.input[hi]) {
+		hi++
+	}
+	b.Offset, b.Input = lo, l.input[lo:hi]
+	return b
+}
+
+// bundleOps returns the longest tail of ops that begins after an item was
+// emitted or ignored at or after lo and remains at or after lo.  The tail is
+// preceded by an OpRestore to the position of that item.
+func bundleOps(ops []Op, lo int) []Op {
+	begin := -1
+	for k := len(ops) - 1; k >= 0; k-- {
+		op := ops[k]
+		if op.Pos < lo || op.Start < lo && (op.Kind == OpEmit || op.Kind == OpIgnore || op.Kind == OpRestore) {
+			break
+		}
+		if op.Kind == OpEmit || op.Kind == OpIgnore {
+			begin = k
+		}
+	}
+	if begin < 0 {
+		return nil
+	}
+	p := ops[begin].Pos
+	return append([]Op{{Kind: OpRestore, Pos: p, Start: p}}, ops[begin+1:]...)
+}
+
+// Replay re-executes the operations of b against its input as with the
+// package function Replay.  Positions of the returned items are offsets in
+// b.Input.
+func (b *Bundle) Replay(opts ...Option) ([]*Item, error) {
+	ops := make([]Op, len(b.Ops))
+	for k, op := range b.Ops {
+		op.Pos -= b.Offset
+		if op.Kind == OpEmit || op.Kind == OpIgnore || op.Kind == OpRestore {
+			op.Start -= b.Offset
+		}
+		ops[k] = op
+	}
+	return Replay(b.Input, ops, append([]Option{WithFilename(b.Filename)}, opts...)...)
+}
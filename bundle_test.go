@@ -0,0 +1,51 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBundle(t *testing.T) {
+	input := strings.Repeat("word ", 20) + "last ?"
+	rec := new(Recorder)
+	l := New(lexRecorded, input, WithRecorder(rec), WithFilename("big.txt"))
+	items := Collect(l)
+	b := l.Bundle(8)
+	if b.Offset != l.Start()-8 || b.Input != input[b.Offset:] {
+		t.Errorf("bundle input %q at %d", b.Input, b.Offset)
+	}
+	if b.Filename != "big.txt" || b.State != "nil" {
+		t.Errorf("bundle filename %q state %q", b.Filename, b.State)
+	}
+	if len(b.Ops) == 0 || len(b.Ops) >= len(rec.Ops()) {
+		t.Fatalf("bundle has %d of %d ops", len(b.Ops), len(rec.Ops()))
+	}
+
+	p, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Bundle
+	if err := json.Unmarshal(p, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := decoded.Replay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tail := items[len(items)-len(replayed):]
+	for i := range replayed {
+		if replayed[i].Value != tail[i].Value || replayed[i].Pos+b.Offset != tail[i].Pos {
+			t.Errorf("item %d: replayed %q at %d, want %q at %d",
+				i, replayed[i].Value, replayed[i].Pos+b.Offset, tail[i].Value, tail[i].Pos)
+		}
+	}
+	if last := replayed[len(replayed)-1]; last.Type != ItemError || last.Value != "question" {
+		t.Errorf("last replayed item %v %q", last.Type, last.Value)
+	}
+}
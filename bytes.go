@@ -0,0 +1,59 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"strings"
+)
+
+// The byte API scans input one byte at a time without UTF-8 decoding, for
+// lexing binary or partially binary formats.  Any byte value, including 0x00
+// and EOF (0x04), may be read and the end of input is signaled separately.
+// Byte and rune methods may be mixed; after AdvanceByte, Backup moves back one
+// byte and Last returns the byte converted to a rune.  Bytes read with the
+// byte API are not checked for the controls reported by WithBidiWarnings.
+
+// AdvanceByte adds one byte of input to the current lexeme and returns it.
+// If there is no input AdvanceByte returns false.
+func (l *Lexer) AdvanceByte() (byte, bool) {
+	if l.pos >= len(l.input) {
+		l.width = 0
+		return 0, false
+	}
+	b := l.input[l.pos]
+	l.last, l.width = rune(b), 1
+	l.pos++
+	l.jumped()
+	return b, true
+}
+
+// PeekByte returns the next byte of input without adding it to the current
+// lexeme.  If there is no input PeekByte returns false.
+func (l *Lexer) PeekByte() (byte, bool) {
+	if l.pos >= len(l.input) {
+		return 0, false
+	}
+	return l.input[l.pos], true
+}
+
+// AcceptByte advances the lexer if the next byte is in valid.  Each byte of
+// valid is a member of the set, even those that are not valid UTF-8.
+func (l *Lexer) AcceptByte(valid string) bool {
+	b, ok := l.PeekByte()
+	if !ok || strings.IndexByte(valid, b) < 0 {
+		return false
+	}
+	l.AdvanceByte()
+	return true
+}
+
+// AcceptBytes advances l's position as long as the next byte is in valid and
+// returns the number of bytes advanced.
+func (l *Lexer) AcceptBytes(valid string) (n int) {
+	for l.AcceptByte(valid) {
+		n++
+	}
+	return
+}
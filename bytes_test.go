@@ -0,0 +1,40 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+func TestLexerBytes(t *testing.T) {
+	// a length-prefixed record containing bytes that are not valid UTF-8
+	start := func(l *Lexer) StateFn {
+		n, ok := l.AdvanceByte()
+		if !ok {
+			return l.Errorf("missing length")
+		}
+		l.Ignore()
+		for i := 0; i < int(n); i++ {
+			if _, ok := l.AdvanceByte(); !ok {
+				return l.Errorf("short record")
+			}
+		}
+		l.Emit(0)
+		if n := l.AcceptBytes("\x00\x04"); n != 2 {
+			return l.Errorf("missing padding")
+		}
+		if _, ok := l.PeekByte(); ok {
+			return l.Errorf("trailing data")
+		}
+		return nil
+	}
+	item := New(start, "\x03\xff\x04\x00\x00\x04").Next()
+	if item.Err() != nil {
+		t.Fatal(item.Err())
+	}
+	if item.Value != "\xff\x04\x00" {
+		t.Errorf("got %q", item.Value)
+	}
+}
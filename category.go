@@ -0,0 +1,86 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import "fmt"
+
+// A Category is a named class of item types, such as the keywords or the
+// literals of a language, so that parsers and highlighters can ask whether
+// an item is any kind of literal without maintaining switch statements.
+// Membership is a bit test.  A Category is immutable once constructed and is
+// safe for concurrent use.
+//
+//	var Literal = lexer.NewCategory("literal", ItemInt, ItemFloat, ItemString)
+//	var Keyword = lexer.NewCategory("keyword", lexer.TypeRange{Lo: KeywordBreak, Hi: KeywordWhile})
+//
+//	if item.Is(Literal) { ... }
+type Category struct {
+	name string
+	bits []uint64
+}
+
+// A TypeRange is the item types from Lo through Hi, inclusive, as a member
+// of NewCategory.
+type TypeRange struct {
+	Lo, Hi ItemType
+}
+
+// NewCategory returns a category named name containing the union of members.
+// Each member must be an ItemType, a TypeRange, or a *Category.  NewCategory
+// panics if given any other type.
+func NewCategory(name string, members ...interface{}) *Category {
+	c := &Category{name: name}
+	for _, m := range members {
+		switch m := m.(type) {
+		case ItemType:
+			c.add(m, m)
+		case TypeRange:
+			c.add(m.Lo, m.Hi)
+		case *Category:
+			if len(m.bits) > len(c.bits) {
+				c.bits = append(c.bits, make([]uint64, len(m.bits)-len(c.bits))...)
+			}
+			for i, w := range m.bits {
+				c.bits[i] |= w
+			}
+		default:
+			panic(fmt.Sprintf("lexer: invalid category member type %T", m))
+		}
+	}
+	return c
+}
+
+func (c *Category) add(lo, hi ItemType) {
+	if lo > hi {
+		return
+	}
+	if n := int(hi)/64 + 1; n > len(c.bits) {
+		c.bits = append(c.bits, make([]uint64, n-len(c.bits))...)
+	}
+	for t := int(lo); t <= int(hi); t++ {
+		c.bits[t/64] |= 1 << (t % 64)
+	}
+}
+
+// Name returns the name of c.
+func (c *Category) Name() string {
+	return c.name
+}
+
+// String returns the name of c.
+func (c *Category) String() string {
+	return c.name
+}
+
+// Has returns true if t is a member of c.
+func (c *Category) Has(t ItemType) bool {
+	i := int(t) / 64
+	return i < len(c.bits) && c.bits[i]&(1<<(t%64)) != 0
+}
+
+// Is returns true if the type of i is a member of c.
+func (i *Item) Is(c *Category) bool {
+	return c.Has(i.Type)
+}
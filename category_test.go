@@ -0,0 +1,53 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+func TestCategory(t *testing.T) {
+	const (
+		itemInt ItemType = iota + 1
+		itemFloat
+		itemString
+		itemIf
+		itemElse
+		itemFor
+		itemSpace
+	)
+	literal := NewCategory("literal", itemInt, itemFloat, itemString)
+	keyword := NewCategory("keyword", TypeRange{itemIf, itemFor})
+	token := NewCategory("token", literal, keyword, ItemError)
+	for _, test := range []struct {
+		c    *Category
+		t    ItemType
+		want bool
+	}{
+		{literal, itemInt, true},
+		{literal, itemString, true},
+		{literal, itemIf, false},
+		{keyword, itemElse, true},
+		{keyword, itemSpace, false},
+		{token, itemFloat, true},
+		{token, itemFor, true},
+		{token, ItemError, true},
+		{token, itemSpace, false},
+		{token, 0, false},
+	} {
+		if got := (&Item{Type: test.t}).Is(test.c); got != test.want {
+			t.Errorf("%v.Has(%d) = %v", test.c, test.t, got)
+		}
+	}
+	if keyword.String() != "keyword" {
+		t.Errorf("name %q", keyword)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("no panic for an invalid member")
+		}
+	}()
+	NewCategory("bad", 1)
+}
@@ -0,0 +1,39 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"unicode/utf8"
+)
+
+// ScanBlockComment advances l over a block comment delimited by open and close,
+// for example "/*" and "*/", if the input begins with open.  If nested is true
+// comments nest, as in Rust and Swift, and the comment ends when each opener
+// has been matched by a closer.
+//
+// An unterminated comment is consumed to the end of the input and reported as
+// an *Error at the position of the innermost unmatched opener.  The error is
+// not emitted.
+func (l *Lexer) ScanBlockComment(open, close string, nested bool) (bool, error) {
+	if !l.AcceptString(open) {
+		return false, nil
+	}
+	openers := []int{l.pos - len(open)}
+	for len(openers) > 0 {
+		switch {
+		case l.AcceptString(close):
+			openers = openers[:len(openers)-1]
+		case nested && l.AcceptString(open):
+			openers = append(openers, l.pos-len(open))
+		case l.pos < len(l.input):
+			// skip checks the rune for bidi controls.
+			_, n := utf8.DecodeRuneInString(l.input[l.pos:])
+			l.skip(n)
+		default:
+			return true, l.wrapAt(openers[len(openers)-1], "unterminated block comment", ErrUnexpectedEOF)
+		}
+	}
+	return true, nil
+}
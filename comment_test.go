@@ -0,0 +1,42 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+func TestScanBlockComment(t *testing.T) {
+	for _, test := range []struct {
+		input  string
+		nested bool
+		lit    string
+		ok     bool
+		errPos int
+	}{
+		{"x /* */", false, "", false, -1},
+		{"/* a */ b */", false, "/* a */", true, -1},
+		{"/* /* a */ b */ c", false, "/* /* a */", true, -1},
+		{"/* /* a */ b */ c", true, "/* /* a */ b */", true, -1},
+		{"/* ok */", true, "/* ok */", true, -1},
+		{"/* é /* a */", true, "/* é /* a */", true, 0},
+		{"/* a /* b /* c */", true, "/* a /* b /* c */", true, 5},
+		{"/* a", false, "/* a", true, 0},
+	} {
+		l := New(func(*Lexer) StateFn { return nil }, test.input)
+		ok, err := l.ScanBlockComment("/*", "*/", test.nested)
+		if ok != test.ok || l.Current() != test.lit {
+			t.Errorf("%q: got %v %q want %v %q", test.input, ok, l.Current(), test.ok, test.lit)
+		}
+		switch {
+		case err == nil && test.errPos >= 0:
+			t.Errorf("%q: expected an error", test.input)
+		case err != nil && test.errPos < 0:
+			t.Errorf("%q: unexpected error %v", test.input, err)
+		case err != nil && err.(*Error).Pos != test.errPos:
+			t.Errorf("%q: error at %d want %d", test.input, err.(*Error).Pos, test.errPos)
+		}
+	}
+}
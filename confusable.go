@@ -0,0 +1,161 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// confusables maps letters that are easily mistaken for ASCII letters to the
+// letters they resemble.
+var confusables = map[rune]rune{
+	0x0131: 'i', // LATIN SMALL LETTER DOTLESS I
+	0x0261: 'g', // LATIN SMALL LETTER SCRIPT G
+	0x0269: 'i', // LATIN SMALL LETTER IOTA
+	0x0391: 'A', // GREEK CAPITAL LETTER ALPHA
+	0x0392: 'B', // GREEK CAPITAL LETTER BETA
+	0x0395: 'E', // GREEK CAPITAL LETTER EPSILON
+	0x0396: 'Z', // GREEK CAPITAL LETTER ZETA
+	0x0397: 'H', // GREEK CAPITAL LETTER ETA
+	0x0399: 'I', // GREEK CAPITAL LETTER IOTA
+	0x039a: 'K', // GREEK CAPITAL LETTER KAPPA
+	0x039c: 'M', // GREEK CAPITAL LETTER MU
+	0x039d: 'N', // GREEK CAPITAL LETTER NU
+	0x039f: 'O', // GREEK CAPITAL LETTER OMICRON
+	0x03a1: 'P', // GREEK CAPITAL LETTER RHO
+	0x03a4: 'T', // GREEK CAPITAL LETTER TAU
+	0x03a5: 'Y', // GREEK CAPITAL LETTER UPSILON
+	0x03a7: 'X', // GREEK CAPITAL LETTER CHI
+	0x03b9: 'i', // GREEK SMALL LETTER IOTA
+	0x03bd: 'v', // GREEK SMALL LETTER NU
+	0x03bf: 'o', // GREEK SMALL LETTER OMICRON
+	0x0405: 'S', // CYRILLIC CAPITAL LETTER DZE
+	0x0406: 'I', // CYRILLIC CAPITAL LETTER BYELORUSSIAN-UKRAINIAN I
+	0x0408: 'J', // CYRILLIC CAPITAL LETTER JE
+	0x0410: 'A', // CYRILLIC CAPITAL LETTER A
+	0x0412: 'B', // CYRILLIC CAPITAL LETTER VE
+	0x0415: 'E', // CYRILLIC CAPITAL LETTER IE
+	0x041a: 'K', // CYRILLIC CAPITAL LETTER KA
+	0x041c: 'M', // CYRILLIC CAPITAL LETTER EM
+	0x041d: 'H', // CYRILLIC CAPITAL LETTER EN
+	0x041e: 'O', // CYRILLIC CAPITAL LETTER O
+	0x0420: 'P', // CYRILLIC CAPITAL LETTER ER
+	0x0421: 'C', // CYRILLIC CAPITAL LETTER ES
+	0x0422: 'T', // CYRILLIC CAPITAL LETTER TE
+	0x0425: 'X', // CYRILLIC CAPITAL LETTER HA
+	0x0430: 'a', // CYRILLIC SMALL LETTER A
+	0x0435: 'e', // CYRILLIC SMALL LETTER IE
+	0x043e: 'o', // CYRILLIC SMALL LETTER O
+	0x0440: 'p', // CYRILLIC SMALL LETTER ER
+	0x0441: 'c', // CYRILLIC SMALL LETTER ES
+	0x0443: 'y', // CYRILLIC SMALL LETTER U
+	0x0445: 'x', // CYRILLIC SMALL LETTER HA
+	0x0455: 's', // CYRILLIC SMALL LETTER DZE
+	0x0456: 'i', // CYRILLIC SMALL LETTER BYELORUSSIAN-UKRAINIAN I
+	0x0458: 'j', // CYRILLIC SMALL LETTER JE
+	0x04bb: 'h', // CYRILLIC SMALL LETTER SHHA
+	0x0501: 'd', // CYRILLIC SMALL LETTER KOMI DE
+	0x051b: 'q', // CYRILLIC SMALL LETTER QA
+	0x051d: 'w', // CYRILLIC SMALL LETTER WE
+}
+
+// scriptNames lists the names of the scripts in unicode.Scripts in order.
+var scriptNames = func() []string {
+	names := make([]string, 0, len(unicode.Scripts))
+	for name := range unicode.Scripts {
+		if name != "Common" && name != "Inherited" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}()
+
+// script returns the name of the script of r, or an empty string if r is
+// used by several scripts.
+func script(r rune) string {
+	if r < 0x80 {
+		if 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' {
+			return "Latin"
+		}
+		return ""
+	}
+	for _, name := range scriptNames {
+		if unicode.Is(unicode.Scripts[name], r) {
+			return name
+		}
+	}
+	return ""
+}
+
+// cjk contains the scripts that are mixed with Han in ordinary text.
+var cjk = map[string]bool{"Hiragana": true, "Katakana": true, "Hangul": true, "Bopomofo": true}
+
+// CheckIdentifier returns a description of why ident could deceive a
+// reader, or an empty string if there is no such reason.  An identifier is
+// suspicious if it mixes letters of different scripts, such as the Latin "p"
+// and Cyrillic "а" of "pаypal", if all of its letters belong to another script
+// but resemble ASCII letters, such as the Cyrillic "сосо", or if it contains a
+// Latin letter that resembles an ASCII letter, such as the dotless "ı".
+// Han may be mixed with the Japanese and Korean scripts.
+func CheckIdentifier(ident string) string {
+	var scripts []string
+	lookalike := true
+	letters := 0
+	var latin rune // a non-ASCII Latin lookalike, if any
+	for _, r := range ident {
+		s := script(r)
+		if s == "" {
+			continue
+		}
+		letters++
+		if _, ok := confusables[r]; !ok {
+			lookalike = false
+		} else if s == "Latin" && latin == 0 {
+			latin = r
+		}
+		if s == "Han" || cjk[s] {
+			s = "Han"
+		}
+		found := false
+		for _, t := range scripts {
+			found = found || t == s
+		}
+		if !found {
+			scripts = append(scripts, s)
+		}
+	}
+	switch {
+	case len(scripts) > 1:
+		return fmt.Sprintf("identifier %q mixes %s scripts", ident, strings.Join(scripts, " and "))
+	case len(scripts) == 1 && scripts[0] != "Latin" && lookalike && letters > 0:
+		skeleton := strings.Map(func(r rune) rune {
+			if c, ok := confusables[r]; ok {
+				return c
+			}
+			return r
+		}, ident)
+		return fmt.Sprintf("identifier %q is written in %s but looks like %q", ident, scripts[0], skeleton)
+	case latin != 0:
+		return fmt.Sprintf("identifier %q contains %U, which looks like %q", ident, latin, string(confusables[latin]))
+	}
+	return ""
+}
+
+// WarnIdentifier checks the current lexeme with CheckIdentifier and emits a
+// warning if it is suspicious.  WarnIdentifier is meant to be called after an
+// identifier has been scanned and before it is emitted, and returns true if a
+// warning was emitted.
+func (l *Lexer) WarnIdentifier() bool {
+	reason := CheckIdentifier(l.Current())
+	if reason == "" {
+		return false
+	}
+	l.Warnf("%s", reason)
+	return true
+}
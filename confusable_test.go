@@ -0,0 +1,42 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+func TestCheckIdentifier(t *testing.T) {
+	for _, test := range []struct {
+		ident  string
+		reason string
+	}{
+		{"paypal", ""},
+		{"café_2", ""},
+		{"привет", ""},
+		{"日本語のテキスト", ""},
+		{"pаypal", `identifier "pаypal" mixes Latin and Cyrillic scripts`},
+		{"сосо", `identifier "сосо" is written in Cyrillic but looks like "coco"`},
+		{"ΑΟ", `identifier "ΑΟ" is written in Greek but looks like "AO"`},
+		{"ɡoogle", `identifier "ɡoogle" contains U+0261, which looks like "g"`},
+	} {
+		if reason := CheckIdentifier(test.ident); reason != test.reason {
+			t.Errorf("%q: got %q, want %q", test.ident, reason, test.reason)
+		}
+	}
+}
+
+func TestWarnIdentifier(t *testing.T) {
+	start := func(l *Lexer) StateFn {
+		l.AcceptRunFunc(func(r rune) bool { return r != ' ' && r != EOF })
+		l.WarnIdentifier()
+		l.Emit(1)
+		return nil
+	}
+	items := Collect(New(start, "pаypal"))
+	if len(items) != 2 || items[0].Type != ItemWarning || items[0].Pos != 0 || items[1].Type != 1 {
+		t.Errorf("unexpected items %v", items)
+	}
+}
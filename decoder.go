@@ -0,0 +1,71 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// A Decoder decodes the input of a lexer created with WithDecoder.
+// DecodeRune returns the first rune encoded in s and its width in bytes.  s is
+// never empty.  An invalid encoding should be returned as utf8.RuneError with
+// a positive width.
+type Decoder interface {
+	DecodeRune(s string) (r rune, width int)
+}
+
+// DecoderFunc adapts a function to the Decoder interface.
+type DecoderFunc func(s string) (rune, int)
+
+// DecodeRune returns fn(s).
+func (fn DecoderFunc) DecodeRune(s string) (rune, int) {
+	return fn(s)
+}
+
+// Decoders for UTF-16 input.  Unpaired surrogates and a final odd byte are
+// decoded as utf8.RuneError.
+var (
+	UTF16LE Decoder = utf16Decoder{0, 1}
+	UTF16BE Decoder = utf16Decoder{1, 0}
+)
+
+// utf16Decoder holds the index of the low and high byte of each code unit.
+type utf16Decoder struct {
+	lo, hi int
+}
+
+func (d utf16Decoder) unit(s string) rune {
+	return rune(s[d.lo]) | rune(s[d.hi])<<8
+}
+
+func (d utf16Decoder) DecodeRune(s string) (rune, int) {
+	if len(s) < 2 {
+		return utf8.RuneError, len(s)
+	}
+	r := d.unit(s)
+	if !utf16.IsSurrogate(r) {
+		return r, 2
+	}
+	if r < 0xdc00 && len(s) >= 4 {
+		if r2 := utf16.DecodeRune(r, d.unit(s[2:])); r2 != utf8.RuneError {
+			return r2, 4
+		}
+	}
+	return utf8.RuneError, 2
+}
+
+// advanceDecoder reads the next rune with l's decoder.
+func (l *Lexer) advanceDecoder() (rune, int) {
+	l.last, l.width = l.decoder.DecodeRune(l.input[l.pos:])
+	if l.width <= 0 {
+		l.last, l.width = utf8.RuneError, 1
+	}
+	if l.pos+l.width > len(l.input) {
+		l.width = len(l.input) - l.pos
+	}
+	l.pos += l.width
+	return l.last, l.width
+}
@@ -0,0 +1,70 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+func encodeUTF16(s string, bigEndian bool) string {
+	var b []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			b = append(b, byte(u>>8), byte(u))
+		} else {
+			b = append(b, byte(u), byte(u>>8))
+		}
+	}
+	return string(b)
+}
+
+func TestDecoderUTF16(t *testing.T) {
+	const text = "hé\U0001F600!"
+	for _, test := range []struct {
+		dec Decoder
+		big bool
+	}{
+		{UTF16LE, false},
+		{UTF16BE, true},
+	} {
+		l := New(lexRunes, encodeUTF16(text, test.big)+"x", WithDecoder(test.dec))
+		var runes []rune
+		var widths []int
+		for {
+			c, n := l.Advance()
+			if n == 0 {
+				break
+			}
+			runes, widths = append(runes, c), append(widths, n)
+		}
+		want := []rune(text + string(utf8.RuneError))
+		if string(runes) != string(want) {
+			t.Errorf("big endian %v: decoded %q", test.big, string(runes))
+		}
+		if len(widths) != 5 || widths[2] != 4 || widths[4] != 1 {
+			t.Errorf("big endian %v: widths %v", test.big, widths)
+		}
+	}
+}
+
+func TestDecoderFunc(t *testing.T) {
+	upper := DecoderFunc(func(s string) (rune, int) {
+		r, n := utf8.DecodeRuneInString(s)
+		if r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		return r, n
+	})
+	l := New(lexRunes, "abc", WithDecoder(upper))
+	if !l.Accept("A") || !l.Accept("B") || l.Accept("c") {
+		t.Errorf("decoder not used by Accept")
+	}
+	l.Emit(1)
+	if i := l.Next(); i.Value != "ab" {
+		t.Errorf("emitted %q", i.Value)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+// A TypeMap translates the item types of a sub-lexer into the type space of
+// the lexer that delegates to it.  A nil TypeMap leaves types unchanged.
+type TypeMap func(ItemType) ItemType
+
+// OffsetTypes returns a TypeMap that adds off to each item type, so that the
+// types of a sub-language can be declared starting from 1 and placed in an
+// unused range of the parent's types.  The special types ItemEOF, ItemError
+// and ItemWarning are not changed.
+func OffsetTypes(off ItemType) TypeMap {
+	return func(t ItemType) ItemType {
+		switch t {
+		case ItemEOF, ItemError, ItemWarning:
+			return t
+		}
+		return t + off
+	}
+}
+
+// MapTypes returns a TypeMap that translates types using m.  Types not in m
+// are not changed.
+func MapTypes(m map[ItemType]ItemType) TypeMap {
+	return func(t ItemType) ItemType {
+		if u, ok := m[t]; ok {
+			return u
+		}
+		return t
+	}
+}
+
+// Delegate lexes the current lexeme with a sub-lexer, such as a script
+// embedded in a markup document, and emits its items in place of the lexeme.
+// The sub-lexer is created by calling newLexer with the text of the lexeme.
+// Each forwarded item has its type translated by types, its position
+// translated into l's input, and its Namespace set to ns.  Items that already
+// have a namespace, because the sub-lexer delegated in turn, have it prefixed
+// with ns and a dot.  The sub-lexer's ItemEOF is not forwarded.
+//
+// Delegated items do not pass through the metadata, interning or error
+// limits of l, and a Recorder records the lexeme as ignored.
+func (l *Lexer) Delegate(ns string, newLexer func(input string) *Lexer, types TypeMap) {
+	off := l.start
+	sub := newLexer(l.Current())
+	for {
+		item := sub.Next()
+		if item == nil || item.Type == ItemEOF {
+			break
+		}
+		i := *item
+		i.format()
+		i.Pos, i.end = item.Pos+off, item.End()+off
+		if types != nil {
+			i.Type = types(i.Type)
+		}
+		if i.Namespace == "" {
+			i.Namespace = ns
+		} else {
+			i.Namespace = ns + "." + i.Namespace
+		}
+		l.enqueue(&i)
+	}
+	if l.rec != nil {
+		l.rec.record(Op{Kind: OpIgnore, Pos: l.pos, Start: l.start})
+	}
+	l.start = l.pos
+}
@@ -0,0 +1,92 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+const itemWord ItemType = 1
+
+func lexDelegateWords(l *Lexer) StateFn {
+	l.IgnoreRun(" ")
+	if l.AcceptRunFunc(func(r rune) bool { return r != ' ' }) == 0 {
+		return nil
+	}
+	l.Emit(itemWord)
+	return lexDelegateWords
+}
+
+func TestLexerDelegate(t *testing.T) {
+	const itemText ItemType = 1
+	words := func(input string) *Lexer { return New(lexDelegateWords, input) }
+	notBrace := func(r rune) bool { return r != '{' && r != '}' }
+	var state StateFn
+	state = func(l *Lexer) StateFn {
+		switch {
+		case l.AcceptString("{"):
+			l.Ignore()
+			l.AcceptRunFunc(notBrace)
+			l.Delegate("script", words, OffsetTypes(10))
+			l.AcceptString("}")
+			l.Ignore()
+		case l.AcceptRunFunc(notBrace) > 0:
+			l.Emit(itemText)
+		default:
+			return nil
+		}
+		return state
+	}
+	l := New(state, "ab{x yz}c")
+	type want struct {
+		typ   ItemType
+		pos   int
+		value string
+		ns    string
+	}
+	for _, w := range []want{
+		{itemText, 0, "ab", ""},
+		{11, 3, "x", "script"},
+		{11, 5, "yz", "script"},
+		{itemText, 8, "c", ""},
+	} {
+		item := l.Next()
+		if item.Type != w.typ || item.Pos != w.pos || item.Value != w.value || item.Namespace != w.ns {
+			t.Fatalf("item %v %d %q %q, want %v", item.Type, item.Pos, item.Value, item.Namespace, w)
+		}
+		if col := item.Position().Column; col != w.pos+1 {
+			t.Errorf("%q column %d", item.Value, col)
+		}
+	}
+	if item := l.Next(); item.Type != ItemEOF {
+		t.Errorf("item %v", item)
+	}
+
+	nested := New(func(l *Lexer) StateFn {
+		l.AcceptRunFunc(func(rune) bool { return true })
+		l.Delegate("outer", func(input string) *Lexer {
+			return New(func(l *Lexer) StateFn {
+				l.AcceptRunFunc(func(rune) bool { return true })
+				l.Delegate("inner", words, nil)
+				return nil
+			}, input)
+		}, nil)
+		return nil
+	}, "a b")
+	if item := nested.Next(); item.Namespace != "outer.inner" || item.Value != "a" {
+		t.Errorf("nested item %q namespace %q", item.Value, item.Namespace)
+	}
+}
+
+func TestTypeMap(t *testing.T) {
+	m := MapTypes(map[ItemType]ItemType{1: 5})
+	if m(1) != 5 || m(2) != 2 {
+		t.Errorf("MapTypes")
+	}
+	off := OffsetTypes(3)
+	if off(1) != 4 || off(ItemError) != ItemError || off(ItemEOF) != ItemEOF {
+		t.Errorf("OffsetTypes")
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Span is a labeled range of a Source, [Start, End).
+type Span struct {
+	Start, End int
+	Label      string
+}
+
+// A Diagnostic is a message about a Source that points at a primary span
+// and, optionally, at secondary spans and notes that explain it, such as the
+// opening delimiter of an unterminated string.  A Diagnostic is rendered for
+// a terminal by WriteTo in the style of the Rust compiler:
+//
+//	error: unterminated string
+//	 --> main.conf:3:8
+//	  |
+//	1 | list = ("abc",
+//	  |        - list opened here
+//	...
+//	3 |   more "def
+//	  |        ^^^^ string starts here
+//	  |
+//	  = note: strings may not span lines
+type Diagnostic struct {
+	Severity  Severity
+	Message   string
+	Source    *Source
+	Primary   Span
+	Secondary []Span
+	Notes     []string
+}
+
+// NewDiagnostic returns a Diagnostic for err whose primary span is the rune
+// at the position of err.
+func NewDiagnostic(err *Error) *Diagnostic {
+	end := err.Pos
+	if err.src != nil && err.Pos < len(err.src.text) {
+		_, n := utf8.DecodeRuneInString(err.src.text[err.Pos:])
+		end += n
+	}
+	return &Diagnostic{
+		Severity: err.Severity(),
+		Message:  err.Message(),
+		Source:   err.src,
+		Primary:  Span{Start: err.Pos, End: end},
+	}
+}
+
+// Span sets the primary span of d to [start, end) with the given label and
+// returns d.
+func (d *Diagnostic) Span(start, end int, label string) *Diagnostic {
+	d.Primary = Span{start, end, label}
+	return d
+}
+
+// Label adds a secondary span [start, end) with the given label to d and
+// returns d.
+func (d *Diagnostic) Label(start, end int, label string) *Diagnostic {
+	d.Secondary = append(d.Secondary, Span{start, end, label})
+	return d
+}
+
+// Note adds a note formatted with fmt.Sprintf to d and returns d.
+func (d *Diagnostic) Note(format string, vs ...interface{}) *Diagnostic {
+	d.Notes = append(d.Notes, fmt.Sprintf(format, vs...))
+	return d
+}
+
+// String returns d as rendered by WriteTo.
+func (d *Diagnostic) String() string {
+	var b strings.Builder
+	d.WriteTo(&b)
+	return b.String()
+}
+
+// A diagnosticMark is a span of a Diagnostic resolved to a line.
+type diagnosticMark struct {
+	line int
+	span Span
+	c    byte // '^' or '-'
+}
+
+// WriteTo renders d to w.  Each span is underlined on the first line it
+// covers, the primary span with carets and secondary spans with hyphens.
+// Lines more than one line apart are separated by "...".
+func (d *Diagnostic) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	fmt.Fprintf(bw, "%v: %s\n", d.Severity, d.Message)
+	if d.Source == nil {
+		for _, note := range d.Notes {
+			fmt.Fprintf(bw, "= note: %s\n", note)
+		}
+		err := bw.Flush()
+		return cw.n, err
+	}
+	marks := []diagnosticMark{d.mark(d.Primary, '^')}
+	for _, s := range d.Secondary {
+		marks = append(marks, d.mark(s, '-'))
+	}
+	sort.SliceStable(marks, func(i, j int) bool {
+		a, b := marks[i], marks[j]
+		return a.line < b.line || a.line == b.line && a.span.Start < b.span.Start
+	})
+	gutter := strings.Repeat(" ", len(strconv.Itoa(marks[len(marks)-1].line)))
+	fmt.Fprintf(bw, "%s--> %v\n", gutter, d.Source.Position(d.Primary.Start))
+	fmt.Fprintf(bw, "%s |\n", gutter)
+	prev := 0
+	for _, m := range marks {
+		start, end := d.Source.line(m.line)
+		text := d.Source.text[start:end]
+		if m.line != prev {
+			if prev > 0 && m.line > prev+1 {
+				bw.WriteString("...\n")
+			}
+			fmt.Fprintf(bw, "%*d | %s\n", len(gutter), m.line, text)
+			prev = m.line
+		}
+		fmt.Fprintf(bw, "%s | ", gutter)
+		// Pad with the tabs of the line so that the marks line up.
+		lo := min(max(m.span.Start, start), end)
+		for _, c := range text[:lo-start] {
+			if c == '\t' {
+				bw.WriteByte('\t')
+			} else {
+				bw.WriteString(strings.Repeat(" ", RuneWidth(c)))
+			}
+		}
+		hi := min(max(m.span.End, lo), end)
+		bw.WriteString(strings.Repeat(string(m.c), max(DisplayWidth(text[lo-start:hi-start]), 1)))
+		if m.span.Label != "" {
+			bw.WriteString(" " + m.span.Label)
+		}
+		bw.WriteByte('\n')
+	}
+	if len(d.Notes) > 0 {
+		fmt.Fprintf(bw, "%s |\n", gutter)
+	}
+	for _, note := range d.Notes {
+		fmt.Fprintf(bw, "%s = note: %s\n", gutter, note)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+func (d *Diagnostic) mark(s Span, c byte) diagnosticMark {
+	return diagnosticMark{line: d.Source.Position(s.Start).Line, span: s, c: c}
+}
+
+// countWriter counts the bytes written to w.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,53 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiagnostic(t *testing.T) {
+	const input = "list = (\"abc\",\n\n  more \"def\n"
+	l := New(lexSparse, input, WithFilename("main.conf"))
+	open := strings.Index(input, "(")
+	quote := strings.LastIndex(input, `"`)
+	err := l.wrapAt(quote, "unterminated string", ErrUnexpectedEOF)
+	d := NewDiagnostic(err).
+		Span(quote, quote+4, "string starts here").
+		Label(open, open+1, "list opened here").
+		Note("strings may not span lines")
+	want := `error: unterminated string
+ --> main.conf:3:8
+  |
+1 | list = ("abc",
+  |        - list opened here
+...
+3 |   more "def
+  |        ^^^^ string starts here
+  |
+  = note: strings may not span lines
+`
+	if got := d.String(); got != want {
+		t.Errorf("diagnostic\n%s\nwant\n%s", got, want)
+	}
+
+	// Spans on one line, a tab, and a caret at the end of the input.
+	l = New(lexSparse, "\tf(a, b", WithFilename("x"))
+	d = NewDiagnostic(l.wrapAt(7, "expected ')'", nil)).Label(2, 3, "to match this")
+	want = `error: expected ')'
+ --> x:1:8
+  |
+1 | 	f(a, b
+  | 	 - to match this
+  | 	      ^
+`
+	if got := d.String(); got != want {
+		t.Errorf("diagnostic\n%s\nwant\n%s", got, want)
+	}
+	if n, _ := d.WriteTo(new(strings.Builder)); n != int64(len(want)) {
+		t.Errorf("wrote %d bytes", n)
+	}
+}
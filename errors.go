@@ -0,0 +1,43 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"errors"
+)
+
+// Sentinel errors wrapped by the errors of this package's helpers, so that
+// callers can classify an *Error with errors.Is.  For example, the errors for
+// unterminated literals, comments and here-documents wrap ErrUnexpectedEOF,
+// and lexemes longer than the limit of WithMaxTokenLength are reported with
+// errors wrapping ErrTokenTooLong.  State functions can wrap them with the %w
+// verb of Errorf.
+var (
+	ErrUnexpectedEOF = errors.New("lexer: unexpected EOF")
+	ErrInvalidUTF8   = errors.New("lexer: invalid UTF-8")
+	ErrTokenTooLong  = errors.New("lexer: token too long")
+)
+
+// wrapper is implemented by errors returned by fmt.Errorf with %w verbs.
+type wrapper interface {
+	Unwrap() error
+}
+
+type multiWrapper interface {
+	Unwrap() []error
+}
+
+// wrapAt returns an error at offset pos that is not emitted, with message msg
+// and wrapping cause.
+func (l *Lexer) wrapAt(pos int, msg string, cause error) *Error {
+	return &Error{Type: ItemError, Pos: pos, Value: msg, src: l.src, cause: cause}
+}
+
+// fail emits an error at offset pos with message msg wrapping cause and
+// returns a nil StateFn.
+func (l *Lexer) fail(pos int, msg string, cause error) StateFn {
+	l.error((*Item)(l.wrapAt(pos, msg, cause)))
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorfWrap(t *testing.T) {
+	start := func(l *Lexer) StateFn {
+		l.Warnf("odd token: %w", ErrTokenTooLong)
+		return l.Errorf("missing terminator: %w", ErrUnexpectedEOF)
+	}
+	l := New(start, "")
+	warn, err := l.Next().Err(), l.Next().Err()
+	if !errors.Is(warn, ErrTokenTooLong) || errors.Is(warn, ErrUnexpectedEOF) {
+		t.Errorf("warning %v does not wrap ErrTokenTooLong", warn)
+	}
+	if !errors.Is(err, ErrUnexpectedEOF) {
+		t.Errorf("error %v does not wrap ErrUnexpectedEOF", err)
+	}
+	if msg := err.(*Error).Message(); msg != "missing terminator: lexer: unexpected EOF" {
+		t.Errorf("message %q", msg)
+	}
+
+	plain := New(func(l *Lexer) StateFn { return l.Errorf("plain %d", 1) }, "").Next().Err()
+	if errors.Unwrap(plain) != nil {
+		t.Errorf("plain error wraps %v", errors.Unwrap(plain))
+	}
+}
+
+func TestHelperErrorsWrap(t *testing.T) {
+	l := New(func(*Lexer) StateFn { return nil }, `"abc`)
+	_, err := l.ScanQuoted(GoString)
+	if !errors.Is(err, ErrUnexpectedEOF) {
+		t.Errorf("unterminated string: %v", err)
+	}
+	l = New(func(*Lexer) StateFn { return nil }, `/* abc`)
+	_, err = l.ScanBlockComment("/*", "*/", false)
+	if !errors.Is(err, ErrUnexpectedEOF) {
+		t.Errorf("unterminated comment: %v", err)
+	}
+}
+
+func TestMaxTokenLength(t *testing.T) {
+	l := New(lexSpaced, "abc defgh ij", WithMaxTokenLength(3))
+	var values []string
+	for item := l.Next(); item.Type != ItemEOF; item = l.Next() {
+		if item.Type == ItemError {
+			if !errors.Is(item.Err(), ErrTokenTooLong) || item.Pos != 4 {
+				t.Errorf("unexpected error %v at %d", item, item.Pos)
+			}
+			continue
+		}
+		values = append(values, item.Value)
+	}
+	if len(values) != 4 || values[0] != "abc" || values[3] != "ij" {
+		t.Errorf("unexpected values %q", values)
+	}
+}
@@ -51,7 +51,7 @@ func ExampleLexer_advance() {
 			item := lex.Next()
 			err := item.Err()
 			if err != nil {
-				return nil, fmt.Errorf("%v (pos %d)", err, item.Pos)
+				return nil, err
 			}
 			switch item.Type {
 			case lexer.ItemEOF:
@@ -76,5 +76,5 @@ func ExampleLexer_advance() {
 
 	// Output:
 	// ["0 success" "1 failure"] <nil>
-	// [] unexpected rune '?' (pos 3)
+	// [] 1:4: unexpected rune '?' (offset 3, near "?.")
 }
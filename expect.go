@@ -0,0 +1,116 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxExpectedRanges is the largest RuneSet described by ExpectedSoFar.
+const maxExpectedRanges = 8
+
+// An expectation is the input attempted at a position, if WithExpected is
+// given.
+type expectation struct {
+	pos  int
+	alts []string
+}
+
+// Expect notes that desc would have been accepted at l's current position,
+// for the messages of lexers created with WithExpected.  It describes input
+// attempted by scanning functions that cannot describe themselves, such as
+// AcceptFunc, as in l.Expect("identifier").
+func (l *Lexer) Expect(desc string) {
+	if l.expect != nil {
+		l.expected(desc)
+	}
+}
+
+// ExpectedSoFar returns descriptions of the input that l attempted and
+// failed to accept at its current position, in the order attempted, if l was
+// created with WithExpected.  Failed calls to Accept, AcceptString, AcceptSet
+// and the AcceptRun methods that stop at the current position are recorded,
+// along with descriptions given to Expect.  Runes are quoted and consecutive
+// runes are described as a range, as in '0'-'9'.  Rune sets of more than a few
+// ranges are not described.
+func (l *Lexer) ExpectedSoFar() []string {
+	if l.expect == nil || l.expect.pos != l.pos {
+		return nil
+	}
+	return append([]string(nil), l.expect.alts...)
+}
+
+// Unexpected emits an error at l's position describing the rune there and the
+// input expected, such as "expected one of '0'-'9', '.', 'e'; found 'x'", and
+// returns nil.  Without descriptions from ExpectedSoFar the message is
+// "unexpected 'x'".
+func (l *Lexer) Unexpected() StateFn {
+	found := "EOF"
+	if l.pos < len(l.input) {
+		r, _ := utf8.DecodeRuneInString(l.input[l.pos:])
+		found = strconv.QuoteRune(r)
+	}
+	var msg string
+	switch alts := l.ExpectedSoFar(); len(alts) {
+	case 0:
+		msg = "unexpected " + found
+	case 1:
+		msg = fmt.Sprintf("expected %s; found %s", alts[0], found)
+	default:
+		msg = fmt.Sprintf("expected one of %s; found %s", strings.Join(alts, ", "), found)
+	}
+	return l.fail(l.pos, msg, nil)
+}
+
+// expected records desc as an alternative at l's position.
+func (l *Lexer) expected(desc ...string) {
+	e := l.expect
+	if e.pos != l.pos {
+		e.pos, e.alts = l.pos, e.alts[:0]
+	}
+outer:
+	for _, d := range desc {
+		for _, a := range e.alts {
+			if a == d {
+				continue outer
+			}
+		}
+		e.alts = append(e.alts, d)
+	}
+}
+
+// expectedRunes records the runes of valid as alternatives.
+func (l *Lexer) expectedRunes(valid string) {
+	rs := make([]runeRange, 0, len(valid))
+	for _, r := range valid {
+		rs = append(rs, runeRange{r, r})
+	}
+	l.expected(describeRanges(newRuneSet(rs).ranges)...)
+}
+
+// expectedSet records the ranges of s as alternatives.
+func (l *Lexer) expectedSet(s *RuneSet) {
+	if len(s.ranges) <= maxExpectedRanges {
+		l.expected(describeRanges(s.ranges)...)
+	}
+}
+
+func describeRanges(rs []runeRange) []string {
+	desc := make([]string, 0, len(rs))
+	for _, r := range rs {
+		switch {
+		case r.lo == r.hi:
+			desc = append(desc, strconv.QuoteRune(r.lo))
+		case r.lo+1 == r.hi:
+			desc = append(desc, strconv.QuoteRune(r.lo), strconv.QuoteRune(r.hi))
+		default:
+			desc = append(desc, fmt.Sprintf("%q-%q", r.lo, r.hi))
+		}
+	}
+	return desc
+}
@@ -0,0 +1,59 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLexerUnexpected(t *testing.T) {
+	number := func(l *Lexer) StateFn {
+		l.AcceptRun("0123456789")
+		if l.Accept(".") {
+			l.AcceptRun("0123456789")
+		}
+		if l.Accept("e") {
+			l.Accept("+-")
+			l.AcceptRun("0123456789")
+		}
+		if l.AcceptString("i") || l.AcceptSet(RuneRange('<', '>')) || l.pos == len(l.input) {
+			l.Emit(1)
+			return nil
+		}
+		l.Expect("end of number")
+		return l.Unexpected()
+	}
+	for _, test := range []struct {
+		input string
+		msg   string
+	}{
+		{"12x", `expected one of '0'-'9', '.', 'e', "i", '<'-'>', end of number; found 'x'`},
+		{"1.5e+x", `expected one of '0'-'9', "i", '<'-'>', end of number; found 'x'`},
+	} {
+		l := New(number, test.input, WithExpected())
+		if item := l.Next(); item.Type != ItemError || item.Value != test.msg {
+			t.Errorf("%q: item %v\nwant %s", test.input, item, test.msg)
+		} else if item.Pos != strings.IndexByte(test.input, 'x') {
+			t.Errorf("%q: error at %d", test.input, item.Pos)
+		}
+	}
+
+	l := New(number, "1x")
+	if item := l.Next(); item.Value != "unexpected 'x'" {
+		t.Errorf("item %v without WithExpected", item)
+	}
+
+	l = New(lexSparse, "ab", WithExpected())
+	l.Accept("xyz")
+	l.AcceptString("ba")
+	if got := strings.Join(l.ExpectedSoFar(), " "); got != `'x'-'z' "ba"` {
+		t.Errorf("expected %s", got)
+	}
+	l.Advance()
+	if got := l.ExpectedSoFar(); got != nil {
+		t.Errorf("expected %q after advancing", got)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// Fingerprint returns a SHA-256 hash of the types and values of items, in
+// order, excluding their positions and items of the types in skip, such as
+// white space and comments.  Inputs whose items have equal fingerprints are
+// lexically identical.  The encoding hashed is stable:  each item contributes
+// its type as a big-endian uint16, the length of its value as a uvarint, and
+// its value.  Lazily formatted error messages are formatted before they are
+// hashed.  ItemEOF is never hashed.
+func Fingerprint(items []*Item, skip ...ItemType) [sha256.Size]byte {
+	h := sha256.New()
+	var buf [2 + binary.MaxVarintLen64]byte
+	for _, i := range items {
+		if i.Type == ItemEOF || skipped(i.Type, skip) {
+			continue
+		}
+		i.format()
+		binary.BigEndian.PutUint16(buf[:2], uint16(i.Type))
+		n := binary.PutUvarint(buf[2:], uint64(len(i.Value)))
+		h.Write(buf[:2+n])
+		h.Write([]byte(i.Value))
+	}
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+func skipped(t ItemType, skip []ItemType) bool {
+	for _, s := range skip {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
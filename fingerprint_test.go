@@ -0,0 +1,60 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func lexSpaced(l *Lexer) StateFn {
+	switch {
+	case l.AcceptRun(" \n") > 0:
+		l.Emit(0)
+	case l.AcceptRun("abcdefghijklmnopqrstuvwxyz") > 0:
+		l.Emit(1)
+	default:
+		if _, n := l.Advance(); n == 0 {
+			return nil
+		}
+		l.Emit(2)
+	}
+	return lexSpaced
+}
+
+func TestFingerprint(t *testing.T) {
+	fp := func(input string, skip ...ItemType) string {
+		sum := Fingerprint(Collect(New(lexSpaced, input)), skip...)
+		return hex.EncodeToString(sum[:])
+	}
+	if fp("a = b", 0) != fp("a\n=  b", 0) {
+		t.Errorf("white space changed the fingerprint")
+	}
+	if fp("a = b") == fp("a\n=  b") {
+		t.Errorf("white space did not change the fingerprint")
+	}
+	if fp("ab", 0) == fp("a b", 0) {
+		t.Errorf("splitting a token did not change the fingerprint")
+	}
+	if fp("a=b") == fp("a+b") {
+		t.Errorf("an operator did not change the fingerprint")
+	}
+	if got, want := fp(""), "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"; got != want {
+		t.Errorf("empty fingerprint %s", got)
+	}
+}
+
+func TestFingerprintLazyError(t *testing.T) {
+	lexLazy := func(msg string) StateFn {
+		return func(l *Lexer) StateFn {
+			return l.LazyErrorf("bad %s", msg)
+		}
+	}
+	a := Fingerprint(Collect(New(lexLazy("a"), "")))
+	b := Fingerprint(Collect(New(lexLazy("b"), "")))
+	if a == b {
+		t.Errorf("lazy error messages did not change the fingerprint")
+	}
+}
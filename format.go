@@ -0,0 +1,152 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// Collect returns the items of l up to, but not including, ItemEOF.
+func Collect(l *Lexer) []*Item {
+	var items []*Item
+	for item := l.Next(); item.Type != ItemEOF; item = l.Next() {
+		items = append(items, item)
+	}
+	return items
+}
+
+// Format is an output format for WriteItems.
+type Format uint8
+
+// Formats supported by WriteItems.
+const (
+	// FormatText writes one item per line as "line:col type value".
+	FormatText Format = iota
+	// FormatTable writes items in aligned columns beneath a header using a
+	// default TablePrinter.
+	FormatTable
+	// FormatJSON writes a JSON array with one object per item.
+	FormatJSON
+	// FormatSexp writes an s-expression with one list per item.
+	FormatSexp
+)
+
+// WriteItems writes items to w in the specified format.  Values are quoted
+// in every format so that control characters are visible.
+func WriteItems(w io.Writer, items []*Item, format Format) error {
+	bw := bufio.NewWriter(w)
+	var err error
+	switch format {
+	case FormatText:
+		for _, item := range items {
+			item.format()
+			fmt.Fprintf(bw, "%v %s %q\n", item.Position(), item.TypeName(), item.Value)
+		}
+	case FormatTable:
+		err = new(TablePrinter).Print(bw, items)
+	case FormatJSON:
+		err = writeJSONItems(bw, items)
+	case FormatSexp:
+		bw.WriteString("(items")
+		for _, item := range items {
+			item.format()
+			pos := item.Position()
+			fmt.Fprintf(bw, "\n  (%s %d %d %d %q)", sexpSymbol(item), pos.Offset, pos.Line, pos.Column, item.Value)
+		}
+		bw.WriteString(")\n")
+	default:
+		err = fmt.Errorf("lexer: unknown format %d", format)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+type jsonItem struct {
+	Type   string `json:"type"`
+	TypeID uint16 `json:"type_id"`
+	Offset int    `json:"offset"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Value  string `json:"value"`
+	NS     string `json:"namespace,omitempty"`
+}
+
+func newJSONItem(item *Item) jsonItem {
+	item.format()
+	pos := item.Position()
+	return jsonItem{
+		Type:   item.TypeName(),
+		TypeID: uint16(item.Type),
+		Offset: pos.Offset,
+		Line:   pos.Line,
+		Column: pos.Column,
+		Value:  item.Value,
+		NS:     item.Namespace,
+	}
+}
+
+func writeJSONItems(w io.Writer, items []*Item) error {
+	js := make([]jsonItem, len(items))
+	for i, item := range items {
+		js[i] = newJSONItem(item)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(js)
+}
+
+// sexpSymbol returns the type name of i if it is a valid symbol and its type
+// number otherwise.
+func sexpSymbol(i *Item) string {
+	name := i.TypeName()
+	for _, c := range name {
+		switch c {
+		case '(', ')', ' ', '"', '\'', ';':
+			return strconv.Itoa(int(i.Type))
+		}
+	}
+	return name
+}
+
+// TablePrinter prints items in aligned columns: the index of each item, its
+// type name, its line:col position, and its quoted value.
+type TablePrinter struct {
+	// MaxValue is the maximum number of runes of a value printed before it is
+	// truncated with an ellipsis.  If zero a default of 40 is used, if
+	// negative values are never truncated.
+	MaxValue int
+}
+
+// Print writes a table of items to w.
+func (p *TablePrinter) Print(w io.Writer, items []*Item) error {
+	max := p.MaxValue
+	if max == 0 {
+		max = 40
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "INDEX\tTYPE\tPOS\tVALUE")
+	for i, item := range items {
+		item.format()
+		fmt.Fprintf(tw, "%d\t%s\t%v\t%s\n", i, item.TypeName(), item.Position(), quoteMax(item.Value, max))
+	}
+	return tw.Flush()
+}
+
+// quoteMax quotes s, escaping control characters, and truncates it with an
+// ellipsis if it is longer than max runes.
+func quoteMax(s string, max int) string {
+	if max < 0 || utf8.RuneCountInString(s) <= max {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprintf("%.*q...", max, s)
+}
@@ -0,0 +1,92 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func formatTestItems() []*Item {
+	var start StateFn
+	start = func(l *Lexer) StateFn {
+		switch {
+		case l.AcceptRun("ab") > 0:
+			l.Emit(0)
+		case l.AcceptRun(" \n") > 0:
+			l.Emit(1)
+		default:
+			return nil
+		}
+		return start
+	}
+	return Collect(New(start, "ab\nba"))
+}
+
+func TestWriteItems(t *testing.T) {
+	for _, test := range []struct {
+		format Format
+		want   string
+	}{
+		{FormatText, "1:1 ItemType(0) \"ab\"\n1:3 ItemType(1) \"\\n\"\n2:1 ItemType(0) \"ba\"\n"},
+		{FormatSexp, "(items\n  (0 0 1 1 \"ab\")\n  (1 2 1 3 \"\\n\")\n  (0 3 2 1 \"ba\"))\n"},
+	} {
+		var buf bytes.Buffer
+		if err := WriteItems(&buf, formatTestItems(), test.format); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.want {
+			t.Errorf("format %d: got\n%s\nwant\n%s", test.format, buf.String(), test.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteItems(&buf, formatTestItems()[:1], FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	want := `[
+  {
+    "type": "ItemType(0)",
+    "type_id": 0,
+    "offset": 0,
+    "line": 1,
+    "column": 1,
+    "value": "ab"
+  }
+]
+`
+	if buf.String() != want {
+		t.Errorf("json: got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestTablePrinter(t *testing.T) {
+	var start StateFn
+	start = func(l *Lexer) StateFn {
+		switch {
+		case l.AcceptRun("abc") > 0:
+			l.Emit(0)
+		case l.AcceptRun(" \n") > 0:
+			l.Emit(1)
+		default:
+			return nil
+		}
+		return start
+	}
+	names := map[ItemType]string{0: "WORD", 1: "SPACE"}
+	items := Collect(New(start, "abcabc\n\n cab", WithTypeNames(names)))
+	var buf bytes.Buffer
+	if err := (&TablePrinter{MaxValue: 4}).Print(&buf, items); err != nil {
+		t.Fatal(err)
+	}
+	want := `INDEX  TYPE   POS  VALUE
+0      WORD   1:1  "abca"...
+1      SPACE  1:7  "\n\n "
+2      WORD   3:2  "cab"
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"unicode/utf8"
+)
+
+// AcceptGlob advances l's position over the longest prefix of the upcoming
+// input matching the wildcard pattern, in which * matches any run of runes, ?
+// matches a single rune, a backslash escapes the following rune, and all
+// other runes match themselves.
+//
+//	l.AcceptGlob("v*.*.*") // "v1.22.3" in "v1.22.3 linux"
+//
+// The search is bounded by the next whitespace rune, as defined by
+// l.Whitespace, and takes time proportional to the length of the pattern
+// times the length of the text searched, without backtracking.  AcceptGlob
+// returns true if l advanced; empty matches are not accepted.
+func (l *Lexer) AcceptGlob(pattern string) bool {
+	g := compileGlob(pattern)
+	space := l.Whitespace()
+	cur := g.closure(make([]bool, len(g)+1), 0)
+	next := make([]bool, len(g)+1)
+	n := 0
+	for off := l.pos; off < len(l.input); {
+		c, w := utf8.DecodeRuneInString(l.input[off:])
+		if space.Contains(c) {
+			break
+		}
+		clear(next)
+		alive := false
+		for i, on := range cur[:len(g)] {
+			if !on {
+				continue
+			}
+			switch tok := g[i]; {
+			case tok.star:
+				g.closure(next, i)
+				alive = true
+			case tok.any || tok.r == c:
+				g.closure(next, i+1)
+				alive = true
+			}
+		}
+		if !alive {
+			break
+		}
+		cur, next = next, cur
+		off += w
+		if cur[len(g)] {
+			n = off - l.pos
+		}
+	}
+	if n == 0 {
+		return false
+	}
+	l.skip(n)
+	return true
+}
+
+// A globToken is an element of a compiled wildcard pattern.
+type globToken struct {
+	r    rune
+	any  bool // ?
+	star bool // *
+}
+
+type glob []globToken
+
+func compileGlob(pattern string) glob {
+	var g glob
+	for i := 0; i < len(pattern); {
+		c, w := utf8.DecodeRuneInString(pattern[i:])
+		i += w
+		switch c {
+		case '*':
+			if n := len(g); n == 0 || !g[n-1].star {
+				g = append(g, globToken{star: true})
+			}
+			continue
+		case '?':
+			g = append(g, globToken{any: true})
+			continue
+		case '\\':
+			if i < len(pattern) {
+				c, w = utf8.DecodeRuneInString(pattern[i:])
+				i += w
+			}
+		}
+		g = append(g, globToken{r: c})
+	}
+	return g
+}
+
+// closure activates state i of g in set along with the states following it
+// that are reachable through stars, and returns set.
+func (g glob) closure(set []bool, i int) []bool {
+	for ; i < len(g) && g[i].star; i++ {
+		set[i] = true
+	}
+	set[i] = true
+	return set
+}
@@ -0,0 +1,48 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLexerAcceptGlob(t *testing.T) {
+	for _, test := range []struct {
+		pattern string
+		input   string
+		match   string
+	}{
+		{"v*.*.*", "v1.22.3 linux", "v1.22.3"},
+		{"v*.*.*", "v1.22 linux", ""},
+		{"v?.?", "v1.2.3", "v1.2"},
+		{"*.go", "a.go.go x.go", "a.go.go"},
+		{"*", "abc def", "abc"},
+		{"*", " abc", ""},
+		{"a**b", "ab", "ab"},
+		{`\*?`, "*x", "*x"},
+		{`\*?`, "ax", ""},
+		{"é?", "éé", "éé"},
+		{"", "abc", ""},
+		{"abc", "ab", ""},
+	} {
+		l := New(lexSparse, test.input)
+		ok := l.AcceptGlob(test.pattern)
+		if l.Current() != test.match || ok != (test.match != "") {
+			t.Errorf("%q %q: matched %q (%v), want %q", test.pattern, test.input, l.Current(), ok, test.match)
+		}
+	}
+}
+
+func BenchmarkAcceptGlob(b *testing.B) {
+	input := strings.Repeat("a", 1000)
+	l := New(lexSparse, input)
+	for i := 0; i < b.N; i++ {
+		l.SetPos(0)
+		if l.AcceptGlob("*a*a*a*b") {
+			b.Fatal("matched")
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE-GO file.
+
+// The number scanner in this file is adapted from the one in the standard
+// library's go/scanner package.
+
+package lexer
+
+import "fmt"
+
+type numberScanner struct {
+	l    *Lexer
+	offs int
+	err  *Error
+}
+
+func (s *numberScanner) ch() rune {
+	c, n := s.l.Peek()
+	if n == 0 {
+		return EOF
+	}
+	return c
+}
+
+func (s *numberScanner) next() {
+	s.l.Advance()
+}
+
+func (s *numberScanner) errorf(pos int, format string, vs ...interface{}) {
+	if s.err == nil {
+		s.err = s.l.errorAt(pos, fmt.Sprintf(format, vs...))
+	}
+}
+
+func (s *numberScanner) scan() (NumberKind, error) {
+	l := s.l
+	switch c := s.ch(); {
+	case isDecimal(c):
+	case c == '.' && len(l.input) > l.pos+1 && isDecimal(rune(l.input[l.pos+1])):
+	default:
+		return NumberNone, nil
+	}
+
+	kind := NumberNone
+	base := 10        // number base
+	prefix := rune(0) // one of 0 (decimal), '0' (0-octal), 'x', 'o', or 'b'
+	digsep := 0       // bit 0: digit present, bit 1: '_' present
+	invalid := -1     // offset of invalid digit in literal, or < 0
+
+	// integer part
+	if s.ch() != '.' {
+		kind = NumberInt
+		if s.ch() == '0' {
+			s.next()
+			switch lower(s.ch()) {
+			case 'x':
+				s.next()
+				base, prefix = 16, 'x'
+			case 'o':
+				s.next()
+				base, prefix = 8, 'o'
+			case 'b':
+				s.next()
+				base, prefix = 2, 'b'
+			default:
+				base, prefix = 8, '0'
+				digsep = 1 // leading 0
+			}
+		}
+		digsep |= s.digits(base, &invalid)
+	}
+
+	// fractional part
+	if s.ch() == '.' {
+		kind = NumberFloat
+		if prefix == 'o' || prefix == 'b' {
+			s.errorf(l.pos, "invalid radix point in %s", litname(prefix))
+		}
+		s.next()
+		digsep |= s.digits(base, &invalid)
+	}
+
+	if digsep&1 == 0 {
+		s.errorf(l.pos, "%s has no digits", litname(prefix))
+	}
+
+	// exponent
+	if e := lower(s.ch()); e == 'e' || e == 'p' {
+		switch {
+		case e == 'e' && prefix != 0 && prefix != '0':
+			s.errorf(l.pos, "%q exponent requires decimal mantissa", s.ch())
+		case e == 'p' && prefix != 'x':
+			s.errorf(l.pos, "%q exponent requires hexadecimal mantissa", s.ch())
+		}
+		s.next()
+		kind = NumberFloat
+		if c := s.ch(); c == '+' || c == '-' {
+			s.next()
+		}
+		ds := s.digits(10, nil)
+		digsep |= ds
+		if ds&1 == 0 {
+			s.errorf(l.pos, "exponent has no digits")
+		}
+	} else if prefix == 'x' && kind == NumberFloat {
+		s.errorf(l.pos, "hexadecimal mantissa requires a 'p' exponent")
+	}
+
+	// suffix 'i'
+	if s.ch() == 'i' {
+		kind = NumberImag
+		s.next()
+	}
+
+	lit := l.input[s.offs:l.pos]
+	if kind == NumberInt && invalid >= 0 {
+		s.errorf(invalid, "invalid digit %q in %s", lit[invalid-s.offs], litname(prefix))
+	}
+	if digsep&2 != 0 {
+		if i := invalidSep(lit); i >= 0 {
+			s.errorf(s.offs+i, "'_' must separate successive digits")
+		}
+	}
+	if s.err != nil {
+		return kind, s.err
+	}
+	return kind, nil
+}
+
+func (s *numberScanner) digits(base int, invalid *int) (digsep int) {
+	if base <= 10 {
+		max := rune('0' + base)
+		for c := s.ch(); isDecimal(c) || c == '_'; c = s.ch() {
+			ds := 1
+			if c == '_' {
+				ds = 2
+			} else if c >= max && *invalid < 0 {
+				*invalid = s.l.pos // record invalid rune offset
+			}
+			digsep |= ds
+			s.next()
+		}
+	} else {
+		for c := s.ch(); isHex(c) || c == '_'; c = s.ch() {
+			ds := 1
+			if c == '_' {
+				ds = 2
+			}
+			digsep |= ds
+			s.next()
+		}
+	}
+	return
+}
+
+func litname(prefix rune) string {
+	switch prefix {
+	case 'x':
+		return "hexadecimal literal"
+	case 'o', '0':
+		return "octal literal"
+	case 'b':
+		return "binary literal"
+	}
+	return "decimal literal"
+}
+
+// invalidSep returns the index of the first invalid separator in x, or -1.
+func invalidSep(x string) int {
+	x1 := ' ' // prefix char, we only care if it's 'x'
+	d := '.'  // digit, one of '_', '0' (a digit), or '.' (anything else)
+	i := 0
+
+	// a prefix counts as a digit
+	if len(x) >= 2 && x[0] == '0' {
+		x1 = lower(rune(x[1]))
+		if x1 == 'x' || x1 == 'o' || x1 == 'b' {
+			d = '0'
+			i = 2
+		}
+	}
+
+	// mantissa and exponent
+	for ; i < len(x); i++ {
+		p := d // previous digit
+		d = rune(x[i])
+		switch {
+		case d == '_':
+			if p != '0' {
+				return i
+			}
+		case isDecimal(d) || x1 == 'x' && isHex(d):
+			d = '0'
+		default:
+			if p == '_' {
+				return i - 1
+			}
+			d = '.'
+		}
+	}
+	if d == '_' {
+		return len(x) - 1
+	}
+
+	return -1
+}
+
+func lower(c rune) rune     { return ('a' - 'A') | c }
+func isDecimal(c rune) bool { return '0' <= c && c <= '9' }
+func isHex(c rune) bool     { return '0' <= c && c <= '9' || 'a' <= lower(c) && lower(c) <= 'f' }
@@ -0,0 +1,69 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"go/token"
+)
+
+// A GoScanner adapts a Lexer to the interface of go/scanner.Scanner, so that
+// code written against go/scanner's output can consume items.  Item types are
+// translated to tokens with a map given to NewGoScanner, and positions are
+// resolved through files added to a token.FileSet.
+type GoScanner struct {
+	l      *Lexer
+	fset   *token.FileSet
+	tokens map[ItemType]token.Token
+	files  map[*Source]*token.File
+}
+
+// NewGoScanner returns a GoScanner reading items from l.  The Source of each
+// item is added to fset as a file when its first item is scanned.
+func NewGoScanner(fset *token.FileSet, l *Lexer, tokens map[ItemType]token.Token) *GoScanner {
+	return &GoScanner{l: l, fset: fset, tokens: tokens, files: make(map[*Source]*token.File)}
+}
+
+// Scan returns the position, token and literal of the next item, following
+// the conventions of go/scanner.Scanner.Scan.  The literal is the item's
+// value for identifiers, basic literals, comments, keywords and semicolons
+// and is empty for operators and delimiters.  ItemEOF is token.EOF.  Error
+// items and items of types missing from the map are token.ILLEGAL, with the
+// error message or the item's value as the literal.
+func (s *GoScanner) Scan() (pos token.Pos, tok token.Token, lit string) {
+	item := s.l.Next()
+	if item == nil {
+		return token.NoPos, token.EOF, ""
+	}
+	pos = s.Pos(item)
+	switch item.Type {
+	case ItemEOF:
+		return pos, token.EOF, ""
+	case ItemError:
+		return pos, token.ILLEGAL, item.String()
+	}
+	tok, ok := s.tokens[item.Type]
+	switch {
+	case !ok:
+		return pos, token.ILLEGAL, item.Value
+	case tok.IsOperator() && tok != token.SEMICOLON:
+		return pos, tok, ""
+	}
+	return pos, tok, item.Value
+}
+
+// Pos returns the token.Pos of item in the file set of s.
+func (s *GoScanner) Pos(item *Item) token.Pos {
+	src := item.Source()
+	if src == nil {
+		return token.NoPos
+	}
+	f := s.files[src]
+	if f == nil {
+		f = s.fset.AddFile(src.Name(), -1, len(src.Text()))
+		f.SetLinesForContent([]byte(src.Text()))
+		s.files[src] = f
+	}
+	return f.Pos(min(item.Pos, f.Size()))
+}
@@ -0,0 +1,71 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"go/token"
+	"testing"
+	"unicode"
+)
+
+func TestGoScanner(t *testing.T) {
+	const (
+		itemIdent ItemType = iota + 1
+		itemInt
+		itemAdd
+		itemSemi
+		itemOther
+	)
+	var state StateFn
+	state = func(l *Lexer) StateFn {
+		l.IgnoreRun(" \n")
+		switch {
+		case l.AcceptRunRange(unicode.Letter) > 0:
+			l.Emit(itemIdent)
+		case l.AcceptRun("0123456789") > 0:
+			l.Emit(itemInt)
+		case l.Accept("+"):
+			l.Emit(itemAdd)
+		case l.Accept(";"):
+			l.Emit(itemSemi)
+		case l.Accept("?"):
+			l.Emit(itemOther)
+		case l.Accept("!"):
+			return l.Errorf("bang")
+		default:
+			return nil
+		}
+		return state
+	}
+	fset := token.NewFileSet()
+	l := New(state, "x +\n12; ? !", WithFilename("a.calc"))
+	s := NewGoScanner(fset, l, map[ItemType]token.Token{
+		itemIdent: token.IDENT,
+		itemInt:   token.INT,
+		itemAdd:   token.ADD,
+		itemSemi:  token.SEMICOLON,
+	})
+	type want struct {
+		pos string
+		tok token.Token
+		lit string
+	}
+	for _, w := range []want{
+		{"a.calc:1:1", token.IDENT, "x"},
+		{"a.calc:1:3", token.ADD, ""},
+		{"a.calc:2:1", token.INT, "12"},
+		{"a.calc:2:3", token.SEMICOLON, ";"},
+		{"a.calc:2:5", token.ILLEGAL, "?"},
+		{"a.calc:2:7", token.ILLEGAL, "bang"},
+	} {
+		pos, tok, lit := s.Scan()
+		if p := fset.Position(pos).String(); p != w.pos || tok != w.tok || lit != w.lit {
+			t.Errorf("%s %v %q, want %v", p, tok, lit, w)
+		}
+	}
+	if _, tok, _ := s.Scan(); tok != token.EOF {
+		t.Errorf("token %v", tok)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// A StateGraph counts the transitions between state functions observed in
+// the operations of a Recorder, accumulated over any number of runs, and
+// writes them as a Graphviz graph.  States are named as in OpState
+// operations.  The zero value is an empty graph.
+type StateGraph struct {
+	visits map[string]int
+	edges  map[Edge]int
+}
+
+// An Edge is a transition between two states of a StateGraph.  The From
+// state of the first transition of a run is empty.
+type Edge struct {
+	From, To string
+}
+
+// AddStates adds states to g without visiting them, so that states never
+// entered appear in the graph.
+func (g *StateGraph) AddStates(states ...StateFn) {
+	g.init()
+	for _, fn := range states {
+		name := stateName(fn)
+		g.visits[name] += 0
+	}
+}
+
+// Add counts the transitions in the operations of one run of a lexer, such
+// as those returned by Recorder.Ops.
+func (g *StateGraph) Add(ops []Op) {
+	g.init()
+	var prev string
+	for _, op := range ops {
+		if op.Kind != OpState {
+			continue
+		}
+		g.visits[op.Text]++
+		g.edges[Edge{prev, op.Text}]++
+		prev = op.Text
+	}
+}
+
+func (g *StateGraph) init() {
+	if g.visits == nil {
+		g.visits = make(map[string]int)
+		g.edges = make(map[Edge]int)
+	}
+}
+
+// Visits returns the number of times the named state was entered.
+func (g *StateGraph) Visits(state string) int {
+	return g.visits[state]
+}
+
+// Count returns the number of times the transition e was observed.
+func (g *StateGraph) Count(e Edge) int {
+	return g.edges[e]
+}
+
+// Unvisited returns the sorted names of the states added with AddStates that
+// were never entered.
+func (g *StateGraph) Unvisited() []string {
+	var names []string
+	for name, n := range g.visits {
+		if n == 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteDOT writes g to w in the DOT language of Graphviz.  Edges are labeled
+// with their counts and nodes with the number of visits, and unvisited states
+// are dashed.  Node labels omit the import path of the state functions.  The
+// first transition of each run leaves the node __start, which is drawn as a
+// point.
+func (g *StateGraph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph states {\n\t__start [shape=point];\n")
+	names := make([]string, 0, len(g.visits))
+	for name := range g.visits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		n := g.visits[name]
+		style := ""
+		if n == 0 {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(bw, "\t%s [label=\"%s\\n%d\"%s];\n", dotID(name), dotLabel(name), n, style)
+	}
+	edges := make([]Edge, 0, len(g.edges))
+	for e := range g.edges {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	for _, e := range edges {
+		from := "__start"
+		if e.From != "" {
+			from = dotID(e.From)
+		}
+		fmt.Fprintf(bw, "\t%s -> %s [label=\"%d\"];\n", from, dotID(e.To), g.edges[e])
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// dotEscaper escapes a string for a quoted DOT string, in which only quotes
+// and backslashes are special.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// dotID returns name as a quoted DOT identifier.
+func dotID(name string) string {
+	return `"` + dotEscaper.Replace(name) + `"`
+}
+
+// dotLabel returns the name of a state function without its import path,
+// escaped for a quoted DOT string.
+func dotLabel(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return dotEscaper.Replace(name)
+}
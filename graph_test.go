@@ -0,0 +1,65 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStateGraph(t *testing.T) {
+	var g StateGraph
+	g.AddStates(lexRecorded, lexSparse)
+	for _, input := range []string{"ab c", "é"} {
+		rec := new(Recorder)
+		Collect(New(lexRecorded, input, WithRecorder(rec)))
+		g.Add(rec.Ops())
+	}
+	const name = "github.com/bmatsuo/go-lexer.lexRecorded"
+	if n := g.Visits(name); n != 5 {
+		t.Errorf("visited %d times", n)
+	}
+	if n := g.Count(Edge{"", name}); n != 2 {
+		t.Errorf("started %d times", n)
+	}
+	if n := g.Count(Edge{name, name}); n != 3 {
+		t.Errorf("%d self transitions", n)
+	}
+	if un := g.Unvisited(); len(un) != 1 || un[0] != "github.com/bmatsuo/go-lexer.lexSparse" {
+		t.Errorf("unvisited %q", un)
+	}
+	var buf bytes.Buffer
+	if err := g.WriteDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`	"github.com/bmatsuo/go-lexer.lexRecorded" [label="go-lexer.lexRecorded\n5"];`,
+		`	"github.com/bmatsuo/go-lexer.lexSparse" [label="go-lexer.lexSparse\n0", style=dashed];`,
+		`	__start -> "github.com/bmatsuo/go-lexer.lexRecorded" [label="2"];`,
+		`	"github.com/bmatsuo/go-lexer.lexRecorded" -> "github.com/bmatsuo/go-lexer.lexRecorded" [label="3"];`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("missing line %s in\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestStateGraphDOTNames(t *testing.T) {
+	var g StateGraph
+	g.Add([]Op{{Kind: OpState, Text: "start"}, {Kind: OpState, Text: "p.s\u00e9\"q\""}})
+	var buf bytes.Buffer
+	if err := g.WriteDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`	__start -> "start" [label="1"];`,
+		`	"start" -> "p.sé\"q\"" [label="1"];`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("missing line %s in\n%s", line, buf.String())
+		}
+	}
+}
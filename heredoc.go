@@ -0,0 +1,130 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"strings"
+)
+
+// ScanRaw advances l over a raw literal which begins with open and ends with
+// the next occurrence of close, if the input begins with open.  Raw literals
+// have no escapes and may contain newlines.  See ScanQuoted.
+func (l *Lexer) ScanRaw(open, close string) (bool, error) {
+	return l.ScanQuoted(QuoteSpec{Open: open, Close: close, Multiline: true})
+}
+
+// A Heredoc is a here-document scanned by ScanHeredoc.
+type Heredoc struct {
+	Tag  string // terminator captured from the opening line
+	Body string // text between the opening and terminating lines
+}
+
+// ScanHeredoc advances l over a here-document introduced by intro, typically
+// "<<".  The intro is followed by an optional indentation mode, a tag, and the
+// end of the line.
+//
+//	<<TAG    body lines are not modified
+//	<<-TAG   leading tabs are stripped from each body line (and the tag line)
+//	<<~TAG   the common indentation of body lines is stripped (and the tag
+//	         line may be indented)
+//
+// The tag is a run of letters, digits, and '_', or any text enclosed in single
+// or double quotes.  The document ends with the first line consisting of the
+// tag alone, which is consumed without its final newline.  ScanHeredoc
+// returns nil without advancing if the input does not begin with intro and a
+// tag.  Errors are returned as an *Error and are not emitted.
+func (l *Lexer) ScanHeredoc(intro string) (*Heredoc, error) {
+	start := l.mark()
+	if !l.AcceptString(intro) {
+		return nil, nil
+	}
+	mode, _ := l.Peek()
+	if mode == '-' || mode == '~' {
+		l.Advance()
+	} else {
+		mode = 0
+	}
+	var tag string
+	switch q, _ := l.Peek(); q {
+	case '\'', '"':
+		l.Advance()
+		from := l.pos
+		if l.AcceptRunNotFunc(func(r rune) bool { return r == q || r == '\n' }) == 0 || !l.Accept(string(q)) {
+			l.reset(start)
+			return nil, nil
+		}
+		tag = l.input[from : l.pos-1]
+	default:
+		from := l.pos
+		l.AcceptRun("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_")
+		tag = l.input[from:l.pos]
+	}
+	if tag == "" {
+		l.reset(start)
+		return nil, nil
+	}
+	l.AcceptRun(" \t")
+	if !l.Accept("\n") {
+		return nil, l.errorAt(l.pos, "expected newline after here-document tag")
+	}
+	body := l.pos
+	for {
+		line := l.pos
+		l.AcceptRunNotAny("\n")
+		text := l.input[line:l.pos]
+		switch mode {
+		case '-':
+			text = strings.TrimLeft(text, "\t")
+		case '~':
+			text = strings.TrimLeft(text, " \t")
+		}
+		if text == tag {
+			end := line - 1
+			if end < body {
+				end = body
+			}
+			return &Heredoc{Tag: tag, Body: stripIndent(l.input[body:end], mode)}, nil
+		}
+		if !l.Accept("\n") {
+			return nil, l.wrapAt(start.pos, "unterminated here-document", ErrUnexpectedEOF)
+		}
+	}
+}
+
+// stripIndent removes indentation from the lines of s according to a
+// here-document mode.
+func stripIndent(s string, mode rune) string {
+	if mode == 0 || s == "" {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	if mode == '-' {
+		for i := range lines {
+			lines[i] = strings.TrimLeft(lines[i], "\t")
+		}
+		return strings.Join(lines, "\n")
+	}
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent <= 0 {
+		return s
+	}
+	for i, line := range lines {
+		if len(line) >= indent {
+			lines[i] = line[indent:]
+		} else {
+			lines[i] = strings.TrimLeft(line, " \t")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,58 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+func TestScanHeredoc(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		tag   string
+		body  string
+		rest  string
+		err   string
+	}{
+		{"<<EOF\nline 1\n  line 2\nEOF\nrest", "EOF", "line 1\n  line 2", "\nrest", ""},
+		{"<<EOF\nEOF", "EOF", "", "", ""},
+		{"<<-END\n\tone\n\t\ttwo\n\tEND\n", "END", "one\ntwo", "\n", ""},
+		{"<<~X\n    a\n      b\n\n    c\n  X", "X", "a\n  b\n\nc", "", ""},
+		{"<<'a b'\nEOF\na b", "a b", "EOF", "", ""},
+		{"<<EOF x\nEOF", "", "", "", "expected newline after here-document tag"},
+		{"<<EOF\nno end\n", "", "", "", "unterminated here-document"},
+		{"<< EOF\n", "", "", "<< EOF\n", ""},
+	} {
+		l := New(func(*Lexer) StateFn { return nil }, test.input)
+		doc, err := l.ScanHeredoc("<<")
+		switch {
+		case err != nil:
+			if err.(*Error).Value != test.err {
+				t.Errorf("%q: unexpected error %v", test.input, err)
+			}
+			continue
+		case test.err != "":
+			t.Errorf("%q: expected error %q", test.input, test.err)
+			continue
+		case doc == nil:
+			if test.tag != "" {
+				t.Errorf("%q: no here-document", test.input)
+			}
+		case doc.Tag != test.tag || doc.Body != test.body:
+			t.Errorf("%q: got tag %q body %q", test.input, doc.Tag, doc.Body)
+		}
+		if rest := l.Input()[l.Pos():]; rest != test.rest {
+			t.Errorf("%q: remaining input %q", test.input, rest)
+		}
+	}
+}
+
+func TestScanRaw(t *testing.T) {
+	l := New(func(*Lexer) StateFn { return nil }, `r#"a "quote" \n"#x`)
+	ok, err := l.ScanRaw(`r#"`, `"#`)
+	if !ok || err != nil || l.Current() != `r#"a "quote" \n"#` {
+		t.Errorf("got %v %v %q", ok, err, l.Current())
+	}
+}
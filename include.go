@@ -0,0 +1,53 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+// savedInput is the scanning state of an input suspended by PushInput.
+type savedInput struct {
+	input string
+	src   *Source
+	start int
+	mark  scanMark
+
+	nextInvalid int // offsets in input of the next problems to report
+	nextBidi    int
+}
+
+// PushInput suspends the current input and continues lexing text, for example
+// the contents of an included file or an expanded macro.  Items emitted while
+// text is the current input have a Source with the given name and positions
+// relative to text.  The Source also records where text was included.
+//
+// The current lexeme of the suspended input, if any, is resumed by PopInput.
+// A state function typically calls PopInput when it reaches the end of a
+// pushed input.
+func (l *Lexer) PushInput(name, text string) {
+	l.inputs = append(l.inputs, savedInput{l.input, l.src, l.start, l.mark(), l.nextInvalid, l.nextBidi})
+	src := l.src.derive(name, text)
+	src.parent, src.at = l.src, l.pos
+	l.input, l.src = text, src
+	l.start, l.pos, l.width, l.last = 0, 0, 0, 0
+	l.nextInvalid, l.nextBidi = 0, 0
+}
+
+// PopInput discards the current input and resumes the input suspended by the
+// most recent call to PushInput.  PopInput returns false if there is no
+// suspended input.
+func (l *Lexer) PopInput() bool {
+	if len(l.inputs) == 0 {
+		return false
+	}
+	in := l.inputs[len(l.inputs)-1]
+	l.inputs = l.inputs[:len(l.inputs)-1]
+	l.input, l.src, l.start = in.input, in.src, in.start
+	l.nextInvalid, l.nextBidi = in.nextInvalid, in.nextBidi
+	l.reset(in.mark)
+	return true
+}
+
+// InputDepth returns the number of inputs suspended by PushInput.
+func (l *Lexer) InputDepth() int {
+	return len(l.inputs)
+}
@@ -0,0 +1,133 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+func lexIncludes(files map[string]string) StateFn {
+	var state StateFn
+	state = func(l *Lexer) StateFn {
+		l.IgnoreRun(" \n")
+		if l.Pos() == len(l.Input()) {
+			if l.PopInput() {
+				return state
+			}
+			return nil
+		}
+		if l.AcceptString("@") {
+			l.AcceptRun("abcdefghijklmnopqrstuvwxyz")
+			name := l.Input()[l.Start()+1 : l.Pos()]
+			l.Ignore()
+			l.PushInput(name, files[name])
+			return state
+		}
+		l.AcceptRunNotAny(" \n")
+		l.Emit(1)
+		return state
+	}
+	return state
+}
+
+func TestPushInput(t *testing.T) {
+	files := map[string]string{
+		"a": "one\n@b two",
+		"b": "three",
+	}
+	l := New(lexIncludes(files), "zero @a four", WithFilename("main"))
+	items := Collect(l)
+	want := []struct {
+		value string
+		pos   string
+	}{
+		{"zero", "main:1:1"},
+		{"one", "a:1:1"},
+		{"three", "b:1:1"},
+		{"two", "a:2:4"},
+		{"four", "main:1:9"},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d: %v", len(items), len(want), items)
+	}
+	for i, w := range want {
+		if items[i].Value != w.value {
+			t.Errorf("item %d: value %q, want %q", i, items[i].Value, w.value)
+		}
+		if pos := items[i].Position().String(); pos != w.pos {
+			t.Errorf("item %d: position %s, want %s", i, pos, w.pos)
+		}
+	}
+	parent, at := items[2].Source().Parent()
+	if parent != items[1].Source() || at != 6 {
+		t.Errorf("parent of b: %v at %d", parent, at)
+	}
+	if parent, _ := items[0].Source().Parent(); parent != nil {
+		t.Errorf("main has parent %v", parent.Name())
+	}
+	if l.InputDepth() != 0 {
+		t.Errorf("input depth %d after EOF", l.InputDepth())
+	}
+}
+
+func TestPushInputRestore(t *testing.T) {
+	l := New(func(*Lexer) StateFn { return nil }, "abc")
+	l.Advance()
+	s := l.Save()
+	l.PushInput("x", "xyz")
+	l.Advance()
+	if l.InputDepth() != 1 || l.Input() != "xyz" {
+		t.Fatalf("input %q depth %d", l.Input(), l.InputDepth())
+	}
+	l.Restore(s)
+	if l.InputDepth() != 0 || l.Input() != "abc" || l.Pos() != 1 {
+		t.Errorf("restored input %q depth %d pos %d", l.Input(), l.InputDepth(), l.Pos())
+	}
+	if l.PopInput() {
+		t.Errorf("PopInput succeeded with no pushed input")
+	}
+}
+
+func TestPushInputChecks(t *testing.T) {
+	// The included text is reported although the parent was scanned past the
+	// same offsets.
+	files := map[string]string{"a": "\u202e"}
+	items := Collect(New(lexIncludes(files), "xxxx @a \u202e", WithBidiWarnings()))
+	var warnings []int
+	for _, item := range items {
+		if item.Type == ItemWarning {
+			warnings = append(warnings, item.Pos)
+		}
+	}
+	if len(warnings) != 2 || warnings[0] != 0 || warnings[1] != 8 {
+		t.Errorf("bidi warnings at %v", warnings)
+	}
+
+	pushed := false
+	var state StateFn
+	state = func(l *Lexer) StateFn {
+		if !pushed && l.Pos() == 2 {
+			pushed = true
+			l.PushInput("b", "\xff")
+		}
+		if _, n := l.Advance(); n == 0 {
+			if l.PopInput() {
+				return state
+			}
+			return nil
+		}
+		l.Ignore()
+		return state
+	}
+	var errs []int
+	for _, item := range Collect(New(state, "\xffab", WithInvalidUTF8(InvalidError))) {
+		if item.Type == ItemError {
+			errs = append(errs, item.Pos)
+		}
+	}
+	if len(errs) != 2 {
+		t.Errorf("invalid UTF-8 errors at %v", errs)
+	}
+}
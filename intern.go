@@ -0,0 +1,44 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"strings"
+	"sync"
+)
+
+// An Interner canonicalizes strings so that equal values share storage.  A
+// Lexer created with WithInterner passes the value of every emitted item
+// through its Interner.  Interned strings are copies and do not retain the
+// input they were scanned from.  An Interner is safe for concurrent use and
+// may be shared by many lexers.
+type Interner struct {
+	mu    sync.Mutex
+	table map[string]string
+}
+
+// NewInterner returns an empty Interner.
+func NewInterner() *Interner {
+	return &Interner{table: make(map[string]string)}
+}
+
+// Intern returns the canonical string equal to s.
+func (in *Interner) Intern(s string) string {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if c, ok := in.table[s]; ok {
+		return c
+	}
+	c := strings.Clone(s)
+	in.table[c] = c
+	return c
+}
+
+// Len returns the number of distinct strings interned.
+func (in *Interner) Len() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return len(in.table)
+}
@@ -0,0 +1,37 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestInterner(t *testing.T) {
+	var start StateFn
+	start = func(l *Lexer) StateFn {
+		l.IgnoreRun(" ")
+		if l.AcceptRun("abc") == 0 {
+			return nil
+		}
+		l.Emit(0)
+		return start
+	}
+	in := NewInterner()
+	l := New(start, "ab ab ca ab", WithInterner(in))
+	var items []*Item
+	for item := l.Next(); item.Type != ItemEOF; item = l.Next() {
+		items = append(items, item)
+	}
+	if in.Len() != 2 {
+		t.Errorf("interned %d strings want 2", in.Len())
+	}
+	if unsafe.StringData(items[0].Value) != unsafe.StringData(items[3].Value) {
+		t.Errorf("equal values were not interned")
+	}
+	if unsafe.StringData(items[0].Value) == unsafe.StringData(l.Input()) {
+		t.Errorf("interned value retains the input")
+	}
+}
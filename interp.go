@@ -0,0 +1,88 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"strings"
+)
+
+// An Interpolation lexes string literals with embedded expressions, such as
+// "a ${b} c".  The literal text is emitted as Chunk items (the quotes are
+// included in the first and last chunks), each interpolation delimiter as a
+// Start or End item, and the embedded expression is lexed by Expr.  Expression
+// states must call CloseExpr before scanning each token.
+//
+// The state stack is used to resume the string after an expression, so
+// strings may be nested inside the expressions of other strings.  An
+// Interpolation holds no lexer state and may be shared.
+type Interpolation struct {
+	Quote       string // opening and closing delimiter of the string
+	Escape      rune   // escape character in string text, or 0
+	Open, Close string // interpolation delimiters, e.g. "${" and "}"
+	Chunk       ItemType
+	Start       ItemType
+	End         ItemType
+	Expr        StateFn // state entered after an Open delimiter
+}
+
+// Lex returns a state that lexes an interpolated string beginning at the
+// current position and then continues with next.
+func (ip *Interpolation) Lex(next StateFn) StateFn {
+	return func(l *Lexer) StateFn {
+		open := l.pos
+		if !l.AcceptString(ip.Quote) {
+			return l.Errorf("expected %q", ip.Quote)
+		}
+		return ip.chunk(l, open, next)
+	}
+}
+
+// chunk lexes string text following the opening quote at offset open.
+func (ip *Interpolation) chunk(l *Lexer, open int, next StateFn) StateFn {
+	for {
+		switch {
+		case l.AcceptString(ip.Quote):
+			l.Emit(ip.Chunk)
+			return next
+		case strings.HasPrefix(l.input[l.pos:], ip.Open):
+			if l.pos > l.start {
+				l.Emit(ip.Chunk)
+			}
+			l.AcceptString(ip.Open)
+			l.Emit(ip.Start)
+			l.PushState(func(l *Lexer) StateFn { return ip.chunk(l, open, next) })
+			return ip.Expr
+		}
+		c, n := l.Advance()
+		switch {
+		case n == 0:
+			l.start = open
+			return l.fail(open, "unterminated string", ErrUnexpectedEOF)
+		case ip.Escape != 0 && c == ip.Escape:
+			l.Advance()
+		}
+	}
+}
+
+// CloseExpr checks for the end of an embedded expression at l's position.  If
+// the Close delimiter follows it is emitted and CloseExpr returns the state
+// that resumes the string.  If the input is consumed an error is emitted and
+// CloseExpr returns a nil state.  In either case CloseExpr returns true and
+// the caller should return the state.  Otherwise CloseExpr returns false and
+// the expression continues.
+//
+// Expressions that may contain the Close delimiter themselves, like braces in
+// "${ {a: 1} }", must track their own nesting and only call CloseExpr at the
+// outermost level.
+func (ip *Interpolation) CloseExpr(l *Lexer) (StateFn, bool) {
+	if l.AcceptString(ip.Close) {
+		l.Emit(ip.End)
+		return l.PopState(), true
+	}
+	if l.pos < len(l.input) {
+		return nil, false
+	}
+	return l.fail(l.start, "unterminated interpolation", ErrUnexpectedEOF), true
+}
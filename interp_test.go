@@ -0,0 +1,73 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+const (
+	interpChunk ItemType = iota
+	interpStart
+	interpEnd
+	interpIdent
+)
+
+func TestInterpolation(t *testing.T) {
+	ip := &Interpolation{Quote: `"`, Escape: '\\', Open: "${", Close: "}", Chunk: interpChunk, Start: interpStart, End: interpEnd}
+	var start StateFn
+	ip.Expr = func(l *Lexer) StateFn {
+		l.IgnoreRun(" ")
+		if next, ok := ip.CloseExpr(l); ok {
+			return next
+		}
+		if c, _ := l.Peek(); c == '"' {
+			return ip.Lex(ip.Expr)
+		}
+		if l.AcceptRun("abcxyz") == 0 {
+			return l.Errorf("unexpected input")
+		}
+		l.Emit(interpIdent)
+		return ip.Expr
+	}
+	start = func(l *Lexer) StateFn {
+		if l.Pos() == len(l.Input()) {
+			return nil
+		}
+		return ip.Lex(start)
+	}
+	items := Collect(New(start, `"a ${ b } \${ ${"x${y}"} c"`))
+	want := []struct {
+		typ   ItemType
+		value string
+	}{
+		{interpChunk, `"a `},
+		{interpStart, "${"},
+		{interpIdent, "b"},
+		{interpEnd, "}"},
+		{interpChunk, ` \${ `},
+		{interpStart, "${"},
+		{interpChunk, `"x`},
+		{interpStart, "${"},
+		{interpIdent, "y"},
+		{interpEnd, "}"},
+		{interpChunk, `"`},
+		{interpEnd, "}"},
+		{interpChunk, ` c"`},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items %v", len(items), items)
+	}
+	for i, item := range items {
+		if item.Type != want[i].typ || item.Value != want[i].value {
+			t.Errorf("item %d: got %d %q want %d %q", i, item.Type, item.Value, want[i].typ, want[i].value)
+		}
+	}
+
+	items = Collect(New(start, `"a ${ b `))
+	if last := items[len(items)-1]; last.Type != ItemError {
+		t.Errorf("expected an error: %v", last)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"unicode/utf8"
+)
+
+// An InvalidPolicy determines how Advance treats bytes of input that are not
+// valid UTF-8.  See WithInvalidUTF8.
+type InvalidPolicy uint8
+
+const (
+	// InvalidReplace reads each invalid byte as utf8.RuneError with a width
+	// of 1, for which IsInvalid returns true.  It is the default.
+	InvalidReplace InvalidPolicy = iota
+	// InvalidSkip discards invalid bytes.  The rune following them is read
+	// with a width that includes the discarded bytes.
+	InvalidSkip
+	// InvalidError emits an ItemError wrapping ErrInvalidUTF8 at the offset
+	// of each invalid byte and otherwise behaves like InvalidReplace.
+	InvalidError
+	// InvalidLatin1 reads each invalid byte as the Latin-1 code point with
+	// the same value, U+0080 through U+00FF, with a width of 1, so that
+	// text in mixed encodings can be lexed on a best-effort basis.  Values of
+	// emitted items contain the bytes of the input unchanged.
+	InvalidLatin1
+)
+
+// advanceInvalid reads the invalid byte at l's position according to l's
+// policy.
+func (l *Lexer) advanceInvalid() (rune, int) {
+	switch l.invalid {
+	case InvalidSkip:
+		i := l.pos + 1
+		for i < len(l.input) {
+			r, n := utf8.DecodeRuneInString(l.input[i:])
+			if r != utf8.RuneError || n != 1 {
+				l.last, l.width = r, i-l.pos+n
+				l.pos = i + n
+				return l.last, l.width
+			}
+			i++
+		}
+		l.pos, l.width = i, 0
+		return EOF, 0
+	case InvalidLatin1:
+		l.last, l.width = rune(l.input[l.pos]), 1
+		l.pos++
+		return l.last, l.width
+	case InvalidError:
+		if l.pos >= l.nextInvalid {
+			l.error((*Item)(l.wrapAt(l.pos, "invalid UTF-8 encoding", ErrInvalidUTF8)))
+			l.nextInvalid = l.pos + 1
+		}
+	}
+	l.last, l.width = utf8.RuneError, 1
+	l.pos++
+	return l.last, l.width
+}
@@ -0,0 +1,92 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"errors"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+func lexRunes(l *Lexer) StateFn {
+	if _, n := l.Advance(); n == 0 {
+		return nil
+	}
+	l.Emit(1)
+	return lexRunes
+}
+
+func TestInvalidPolicy(t *testing.T) {
+	const input = "a\xff\xfeb\xff"
+	for _, test := range []struct {
+		policy InvalidPolicy
+		values []string
+	}{
+		{InvalidReplace, []string{"a", "\xff", "\xfe", "b", "\xff"}},
+		{InvalidSkip, []string{"a", "\xff\xfeb"}},
+		{InvalidError, []string{"a", "Error", "\xff", "Error", "\xfe", "b", "Error", "\xff"}},
+	} {
+		items := Collect(New(lexRunes, input, WithInvalidUTF8(test.policy)))
+		if len(items) != len(test.values) {
+			t.Errorf("policy %d: got %d items, want %d: %q", test.policy, len(items), len(test.values), items)
+			continue
+		}
+		for i, v := range test.values {
+			if v == "Error" {
+				if !errors.Is(items[i].Err(), ErrInvalidUTF8) {
+					t.Errorf("policy %d item %d: %v is not an invalid UTF-8 error", test.policy, i, items[i])
+				}
+				continue
+			}
+			if items[i].Value != v {
+				t.Errorf("policy %d item %d: %q, want %q", test.policy, i, items[i].Value, v)
+			}
+		}
+	}
+}
+
+func TestInvalidBackup(t *testing.T) {
+	l := New(lexRunes, "\xffa")
+	if c, n := l.Peek(); c != utf8.RuneError || n != 1 || l.Pos() != 0 {
+		t.Errorf("Peek returned %q %d at %d", c, n, l.Pos())
+	}
+	if l.Accept("a") || l.Pos() != 0 {
+		t.Errorf("Accept of invalid byte moved to %d", l.Pos())
+	}
+	if l.AcceptFunc(func(rune) bool { return true }) || l.Pos() != 0 {
+		t.Errorf("AcceptFunc of invalid byte moved to %d", l.Pos())
+	}
+	l.Advance()
+	if !l.Accept("a") {
+		t.Errorf("Accept failed after an invalid byte")
+	}
+	l = New(lexRunes, "\xff\xff", WithInvalidUTF8(InvalidSkip))
+	if c, n := l.Advance(); c != EOF || n != 0 || l.Pos() != 2 {
+		t.Errorf("skipped to %q %d at %d", c, n, l.Pos())
+	}
+}
+
+func TestInvalidLatin1(t *testing.T) {
+	l := New(lexRunes, "caf\xe9 \xff", WithInvalidUTF8(InvalidLatin1))
+	var runes []rune
+	for {
+		c, n := l.Advance()
+		if n == 0 {
+			break
+		}
+		if n != 1 {
+			t.Errorf("width %d for %q", n, c)
+		}
+		runes = append(runes, c)
+	}
+	if got := string(runes); got != "caf\u00e9 \u00ff" {
+		t.Errorf("read %q", got)
+	}
+	l = New(lexRunes, "\xe9t\xe9", WithInvalidUTF8(InvalidLatin1))
+	if n := l.AcceptRunFunc(unicode.IsLetter); n != 3 || l.Current() != "\xe9t\xe9" {
+		t.Errorf("accepted %d runes %q", n, l.Current())
+	}
+}
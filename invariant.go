@@ -0,0 +1,44 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+)
+
+// invariantf panics with a description of a violated invariant, the state
+// function executing and l's position.
+func (l *Lexer) invariantf(format string, vs ...interface{}) {
+	panic(fmt.Sprintf("lexer: %s at %v (offset %d) in state %s",
+		fmt.Sprintf(format, vs...), l.src.Position(l.pos), l.pos, stateName(l.state)))
+}
+
+// checkPos verifies that l's start and position are within the input.
+func (l *Lexer) checkPos() {
+	if l.start < 0 || l.start > l.pos || l.pos > len(l.input) {
+		l.invariantf("start %d and position %d outside of input [0, %d]", l.start, l.pos, len(l.input))
+	}
+}
+
+// checkBackup verifies that Backup follows a rune read with Advance.
+func (l *Lexer) checkBackup() {
+	if l.backedUp && l.width > 0 {
+		l.invariantf("Backup called twice")
+	}
+	l.backedUp = true
+}
+
+// checkEmit verifies that an item of type t may be emitted for the current
+// lexeme.
+func (l *Lexer) checkEmit(t ItemType) {
+	switch t {
+	case ItemEOF, ItemError, ItemWarning:
+		l.invariantf("Emit of reserved type %v", t)
+	}
+	if l.start == l.pos {
+		l.invariantf("Emit of empty %s item, use EmitMarker for zero-width items", l.TypeName(t))
+	}
+	l.checkPos()
+}
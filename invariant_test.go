@@ -0,0 +1,53 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func lexInvariantPanic(fn StateFn) (msg string) {
+	defer func() {
+		if e := recover(); e != nil {
+			msg = fmt.Sprint(e)
+		}
+	}()
+	Collect(New(fn, "ab", WithInvariants()))
+	return ""
+}
+
+func lexDoubleBackup(l *Lexer) StateFn {
+	l.Advance()
+	l.Backup()
+	l.Backup()
+	return nil
+}
+
+func TestInvariants(t *testing.T) {
+	for _, test := range []struct {
+		fn   StateFn
+		want string
+	}{
+		{lexDoubleBackup, "lexer: Backup called twice at 1:1 (offset 0) in state github.com/bmatsuo/go-lexer.lexDoubleBackup"},
+		{func(l *Lexer) StateFn { l.Emit(1); return nil }, "Emit of empty ItemType(1) item"},
+		{func(l *Lexer) StateFn { l.Advance(); l.Emit(ItemError); return nil }, "Emit of reserved type Error"},
+		{func(l *Lexer) StateFn { l.Advance(); l.Ignore(); l.Backup(); return nil }, "start 1 and position 0"},
+		{func(l *Lexer) StateFn {
+			l.Advance()
+			l.Backup()
+			l.Peek()
+			l.Advance()
+			l.Emit(1)
+			return nil
+		}, ""},
+	} {
+		msg := lexInvariantPanic(test.fn)
+		if test.want == "" && msg != "" || !strings.Contains(msg, test.want) {
+			t.Errorf("panic %q, want %q", msg, test.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// JSONDiagnosticsVersion is the version of the schema written by
+// JSONDiagnostics.  It changes only if the schema changes incompatibly.
+const JSONDiagnosticsVersion = 1
+
+// A JSONDiagnostic is the machine-readable form of an error or warning
+// written by JSONDiagnostics.  The span from Start to End covers the rune at
+// the position of the error, and is empty at the end of a line or the input.
+// Fields are omitted when unknown.
+type JSONDiagnostic struct {
+	Severity string        `json:"severity"` // "error" or "warning"
+	Code     string        `json:"code,omitempty"`
+	Message  string        `json:"message"`
+	File     string        `json:"file,omitempty"`
+	Start    *JSONPosition `json:"start,omitempty"`
+	End      *JSONPosition `json:"end,omitempty"`
+	Excerpt  string        `json:"excerpt,omitempty"` // input following Start on its line
+}
+
+// A JSONPosition is a Position in a JSONDiagnostic.  Columns are counted as
+// by Position.
+type JSONPosition struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// JSONDiagnostics encodes lexer errors and warnings as a JSON document with a
+// stable schema, so that editors and CI wrappers can read them without
+// scraping messages:
+//
+//	{
+//	  "version": 1,
+//	  "diagnostics": [
+//	    {
+//	      "severity": "error",
+//	      "code": "E001",
+//	      "message": "unterminated string",
+//	      "file": "main.conf",
+//	      "start": {"offset": 7, "line": 1, "column": 8},
+//	      "end": {"offset": 8, "line": 1, "column": 9},
+//	      "excerpt": "\"abc"
+//	    }
+//	  ]
+//	}
+type JSONDiagnostics struct {
+	// Code returns the code of an error.  If Code is nil codes are omitted.
+	Code func(*Error) string
+}
+
+type jsonDiagnostics struct {
+	Version     int              `json:"version"`
+	Diagnostics []JSONDiagnostic `json:"diagnostics"`
+}
+
+// Diagnostic returns the JSONDiagnostic for err.  Errors that are not *Error
+// values have only a severity and message.
+func (j *JSONDiagnostics) Diagnostic(err error) JSONDiagnostic {
+	var e *Error
+	if !errors.As(err, &e) {
+		return JSONDiagnostic{Severity: SeverityError.String(), Message: err.Error()}
+	}
+	d := JSONDiagnostic{Severity: e.Severity().String(), Message: e.Message()}
+	if j.Code != nil {
+		d.Code = j.Code(e)
+	}
+	if e.src == nil {
+		return d
+	}
+	start := e.Position()
+	end := start
+	if r, n := utf8.DecodeRuneInString(e.src.text[start.Offset:]); n > 0 && r != '\n' {
+		end = e.src.Position(start.Offset + n)
+	}
+	d.File = start.Filename
+	d.Start = &JSONPosition{start.Offset, start.Line, start.Column}
+	d.End = &JSONPosition{end.Offset, end.Line, end.Column}
+	d.Excerpt = e.Excerpt()
+	return d
+}
+
+// Write writes a document containing a diagnostic for each of errs, such as
+// the errors returned by Lexer.Errors, to w.
+func (j *JSONDiagnostics) Write(w io.Writer, errs []error) error {
+	doc := jsonDiagnostics{Version: JSONDiagnosticsVersion, Diagnostics: []JSONDiagnostic{}}
+	for _, err := range errs {
+		doc.Diagnostics = append(doc.Diagnostics, j.Diagnostic(err))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
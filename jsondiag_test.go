@@ -0,0 +1,76 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJSONDiagnostics(t *testing.T) {
+	l := New(lexSparse, "list = \"abc\n", WithFilename("main.conf"))
+	errs := []error{
+		l.wrapAt(7, "unterminated string", ErrUnexpectedEOF),
+		l.wrapAt(11, "trailing newline", nil),
+		errors.New("lexer crashed"),
+	}
+	errs[1].(*Error).Type = ItemWarning
+	j := &JSONDiagnostics{Code: func(err *Error) string {
+		if errors.Is(err, ErrUnexpectedEOF) {
+			return "E001"
+		}
+		return ""
+	}}
+	var b strings.Builder
+	if err := j.Write(&b, errs); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "version": 1,
+  "diagnostics": [
+    {
+      "severity": "error",
+      "code": "E001",
+      "message": "unterminated string",
+      "file": "main.conf",
+      "start": {
+        "offset": 7,
+        "line": 1,
+        "column": 8
+      },
+      "end": {
+        "offset": 8,
+        "line": 1,
+        "column": 9
+      },
+      "excerpt": "\"abc"
+    },
+    {
+      "severity": "warning",
+      "message": "trailing newline",
+      "file": "main.conf",
+      "start": {
+        "offset": 11,
+        "line": 1,
+        "column": 12
+      },
+      "end": {
+        "offset": 11,
+        "line": 1,
+        "column": 12
+      }
+    },
+    {
+      "severity": "error",
+      "message": "lexer crashed"
+    }
+  ]
+}
+`
+	if got := b.String(); got != want {
+		t.Errorf("diagnostics\n%s\nwant\n%s", got, want)
+	}
+}
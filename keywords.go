@@ -0,0 +1,103 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"unicode/utf8"
+)
+
+// Keywords classifies identifiers as the keywords of a language and suggests
+// the keyword a misspelled identifier was probably meant to be.
+type Keywords struct {
+	Types map[string]ItemType // item type of each keyword
+
+	// MaxDistance is the greatest edit distance at which Suggest proposes a
+	// keyword.  If MaxDistance is zero no suggestions are made.
+	MaxDistance int
+}
+
+// Lookup returns the item type of the keyword word.
+func (k *Keywords) Lookup(word string) (ItemType, bool) {
+	t, ok := k.Types[word]
+	return t, ok
+}
+
+// Suggest returns the keyword nearest to word by EditDistance, if word is
+// not a keyword and the distance is at most k.MaxDistance and half the length
+// of word.  Ties are broken in favor of the lexically smallest keyword.
+func (k *Keywords) Suggest(word string) (string, bool) {
+	if _, ok := k.Types[word]; ok || k.MaxDistance <= 0 {
+		return "", false
+	}
+	best, dist := "", k.MaxDistance+1
+	if n := utf8.RuneCountInString(word)/2 + 1; n < dist {
+		dist = n
+	}
+	for kw := range k.Types {
+		if d := EditDistance(word, kw); d < dist || d == dist && best != "" && kw < best {
+			best, dist = kw, d
+		}
+	}
+	return best, best != ""
+}
+
+// EmitKeyword emits the current lexeme as an item of its keyword type, or of
+// type ident if it is not a keyword.
+func (l *Lexer) EmitKeyword(k *Keywords, ident ItemType) {
+	if t, ok := k.Lookup(l.Current()); ok {
+		l.Emit(t)
+		return
+	}
+	l.Emit(ident)
+}
+
+// ExpectKeyword emits the current lexeme as an item of its keyword type and
+// returns true.  If the lexeme is not a keyword ExpectKeyword emits an error
+// naming the kind of word expected, such as "unknown directive 'inlcude'",
+// with a suggestion from k.Suggest if there is one, as in "unknown directive
+// 'inlcude'; did you mean 'include'?", and returns false.
+func (l *Lexer) ExpectKeyword(k *Keywords, kind string) bool {
+	word := l.Current()
+	if t, ok := k.Lookup(word); ok {
+		l.Emit(t)
+		return true
+	}
+	if s, ok := k.Suggest(word); ok {
+		l.Errorf("unknown %s '%s'; did you mean '%s'?", kind, word, s)
+	} else {
+		l.Errorf("unknown %s '%s'", kind, word)
+	}
+	return false
+}
+
+// EditDistance returns the number of rune insertions, deletions,
+// substitutions and transpositions of adjacent runes needed to change a into
+// b, the optimal string alignment distance.
+func EditDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// Rows i-2, i-1 and i of the distance matrix.
+	prev2 := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d := min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d = min(d, prev2[j-2]+1)
+			}
+			cur[j] = d
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(t)]
+}
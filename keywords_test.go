@@ -0,0 +1,105 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		d    int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"include", "include", 0},
+		{"inlcude", "include", 1},
+		{"includ", "include", 1},
+		{"inclxde", "include", 1},
+		{"kitten", "sitting", 3},
+		{"ca", "abc", 3},
+		{"héllo", "hello", 1},
+	} {
+		if d := EditDistance(test.a, test.b); d != test.d {
+			t.Errorf("EditDistance(%q, %q) = %d, want %d", test.a, test.b, d, test.d)
+		}
+	}
+}
+
+func TestKeywordsSuggest(t *testing.T) {
+	k := &Keywords{Types: map[string]ItemType{"include": 1, "define": 2, "ifdef": 3, "if": 4}, MaxDistance: 2}
+	for _, test := range []struct {
+		word, suggest string
+	}{
+		{"inlcude", "include"},
+		{"defin", "define"},
+		{"ifdf", "ifdef"},
+		{"of", "if"},
+		{"x", ""},
+		{"pragma", ""},
+		{"include", ""},
+	} {
+		if s, ok := k.Suggest(test.word); s != test.suggest || ok != (test.suggest != "") {
+			t.Errorf("Suggest(%q) = %q, %v", test.word, s, ok)
+		}
+	}
+	k.MaxDistance = 0
+	if _, ok := k.Suggest("inlcude"); ok {
+		t.Errorf("suggestion with MaxDistance 0")
+	}
+}
+
+func TestLexerExpectKeyword(t *testing.T) {
+	const itemIdent ItemType = 10
+	k := &Keywords{Types: map[string]ItemType{"include": 1, "define": 2}, MaxDistance: 2}
+	var state StateFn
+	state = func(l *Lexer) StateFn {
+		l.IgnoreRun(" ")
+		if !l.Accept("#") {
+			if l.AcceptRun("abcdefghijklmnopqrstuvwxyz") == 0 {
+				return nil
+			}
+			l.EmitKeyword(k, itemIdent)
+			return state
+		}
+		l.Ignore()
+		l.AcceptRun("abcdefghijklmnopqrstuvwxyz")
+		if !l.ExpectKeyword(k, "directive") {
+			return nil
+		}
+		return state
+	}
+	for _, test := range []struct {
+		input string
+		types []ItemType
+		err   string
+	}{
+		{"#include x define", []ItemType{1, itemIdent, 2}, ""},
+		{"#inlcude x", nil, "unknown directive 'inlcude'; did you mean 'include'?"},
+		{"#pragma x", nil, "unknown directive 'pragma'"},
+	} {
+		l := New(state, test.input)
+		var types []ItemType
+		var err string
+		for item := l.Next(); item.Type != ItemEOF; item = l.Next() {
+			if item.Type == ItemError {
+				err = item.Value
+				continue
+			}
+			types = append(types, item.Type)
+		}
+		if len(types) != len(test.types) || err != test.err {
+			t.Errorf("%q: items %v error %q", test.input, types, err)
+			continue
+		}
+		for i := range types {
+			if types[i] != test.types[i] {
+				t.Errorf("%q: items %v", test.input, types)
+			}
+		}
+	}
+}
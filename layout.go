@@ -0,0 +1,122 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+// A Layout describes an off-side rule, in which the columns of items delimit
+// blocks, in the style of Haskell.  Layout tracks the column of the first
+// item of each implicit block and inserts synthetic items of type Open, Close
+// and Separator:
+//
+//   - When Opens reports true for an item, an Open item is inserted before the
+//     next item and a block begins at its column.
+//   - When the first item on a line is left of the innermost block's column, a
+//     Close item is inserted for each block it is left of.
+//   - When the first item on a line is at the innermost block's column, a
+//     Separator item is inserted before it.
+//   - When Closes reports true for an item, a Close item for the innermost
+//     block is inserted before it.
+//
+// All blocks are closed at the end of the input.  Columns are those reported
+// by Item.Position.
+type Layout struct {
+	Open      ItemType // type of items inserted at the beginning of a block
+	Close     ItemType // type of items inserted at the end of a block
+	Separator ItemType // type of items inserted between lines of a block
+
+	Opens  func(i *Item) bool // whether a block begins after i
+	Closes func(i *Item) bool // whether i ends the innermost block; may be nil
+	Ignore []ItemType         // types of items that do not affect layout
+}
+
+// layout holds the state of an off-side rule.
+type layout struct {
+	*Layout
+	ignore map[ItemType]bool
+	layoutState
+}
+
+// layoutState is the part of a layout saved by Lexer.Save.
+type layoutState struct {
+	blocks  []int // column of each open block, innermost last
+	pending bool  // a block begins at the next item
+	line    int   // line of the last item
+}
+
+func newLayout(spec *Layout) *layout {
+	lay := &layout{Layout: spec, ignore: make(map[ItemType]bool)}
+	for _, t := range spec.Ignore {
+		lay.ignore[t] = true
+	}
+	return lay
+}
+
+func (lay *layout) save() layoutState {
+	s := lay.layoutState
+	s.blocks = append([]int(nil), s.blocks...)
+	return s
+}
+
+func (lay *layout) restore(s layoutState) {
+	lay.layoutState = s
+	lay.blocks = append([]int(nil), s.blocks...)
+}
+
+// before inserts layout items ahead of i.
+func (lay *layout) before(l *Lexer, i *Item) {
+	if i.Type == ItemError || i.Type == ItemWarning || i.synthetic || lay.ignore[i.Type] {
+		return
+	}
+	pos := i.Position()
+	newline := pos.Line > lay.line
+	lay.line = pos.Line
+	if lay.pending {
+		lay.pending = false
+		l.push(lay.item(lay.Open, i))
+		if n := len(lay.blocks); n == 0 || pos.Column > lay.blocks[n-1] {
+			lay.blocks = append(lay.blocks, pos.Column)
+			lay.opens(i)
+			return
+		}
+		l.push(lay.item(lay.Close, i))
+	}
+	if newline {
+		for n := len(lay.blocks); n > 0 && pos.Column < lay.blocks[n-1]; n-- {
+			lay.blocks = lay.blocks[:n-1]
+			l.push(lay.item(lay.Close, i))
+		}
+		if n := len(lay.blocks); n > 0 && pos.Column == lay.blocks[n-1] {
+			l.push(lay.item(lay.Separator, i))
+		}
+	}
+	if lay.Closes != nil && len(lay.blocks) > 0 && lay.Closes(i) {
+		lay.blocks = lay.blocks[:len(lay.blocks)-1]
+		l.push(lay.item(lay.Close, i))
+	}
+	lay.opens(i)
+}
+
+func (lay *layout) opens(i *Item) {
+	if lay.Opens != nil && lay.Opens(i) {
+		lay.pending = true
+	}
+}
+
+// atEOF closes the open blocks and returns true if any were closed.
+func (lay *layout) atEOF(l *Lexer) bool {
+	if len(lay.blocks) == 0 {
+		return false
+	}
+	eof := l.eof()
+	for range lay.blocks {
+		l.push(lay.item(lay.Close, eof))
+	}
+	lay.blocks = lay.blocks[:0]
+	return true
+}
+
+// item returns a synthetic item of type t positioned at i.
+func (lay *layout) item(t ItemType, i *Item) *Item {
+	return &Item{Type: t, Pos: i.Pos, src: i.src, end: i.Pos, synthetic: true}
+}
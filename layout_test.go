@@ -0,0 +1,64 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	layoutOpen ItemType = iota + 20
+	layoutClose
+	layoutSep
+)
+
+var testLayout = &Layout{
+	Open:      layoutOpen,
+	Close:     layoutClose,
+	Separator: layoutSep,
+	Opens: func(i *Item) bool {
+		return i.Value == "let" || i.Value == "do"
+	},
+	Closes: func(i *Item) bool {
+		return i.Value == "in"
+	},
+}
+
+func layoutString(items []*Item) string {
+	var vals []string
+	for _, i := range items {
+		switch i.Type {
+		case layoutOpen:
+			vals = append(vals, "{")
+		case layoutClose:
+			vals = append(vals, "}")
+		case layoutSep:
+			vals = append(vals, ";")
+		default:
+			vals = append(vals, i.Value)
+		}
+	}
+	return strings.Join(vals, " ")
+}
+
+func TestLayout(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		want  string
+	}{
+		{"let\n  a = b\n  c = d\nin e", "let { a = b ; c = d } in e"},
+		{"let a = b in c", "let { a = b } in c"},
+		{"f = do x\n       y\nz", "f = do { x ; y } z"},
+		{"do a\n   do b\n      c", "do { a ; do { b ; c } }"},
+		{"do a\n do\n b", "do { a } do { b }"},
+		{"do a\n   do\n   b", "do { a ; do { } ; b }"},
+	} {
+		items := Collect(New(lexSemiTokens, test.input, WithLayout(testLayout)))
+		if got := layoutString(items); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.input, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lexdebug provides an http.Handler for troubleshooting lexers.  The
+// handler lexes text posted to it with a registered lexer and responds with
+// the items as JSON or as an HTML table.
+//
+//	http.Handle("/debug/lexer", &lexdebug.Handler{
+//		Lexers: map[string]lexdebug.Factory{"json": newJSONLexer},
+//	})
+package lexdebug
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	lexer "github.com/bmatsuo/go-lexer"
+)
+
+// A Factory returns a new lexer for input.
+type Factory func(input string) *lexer.Lexer
+
+// Default limits of a Handler.
+const (
+	DefaultMaxBytes = 1 << 20
+	DefaultMaxItems = 100000
+)
+
+// Handler lexes the body of POST requests.  The lexer is named by the query
+// parameter "lexer", which may be omitted if only one is registered.  The
+// response is JSON in the format of lexer.FormatJSON unless the parameter
+// "format" is "html" or the request accepts text/html, in which case it is
+// an HTML table.  A form-encoded body is read from its "input" field, so a
+// GET request serves an HTML form for pasting input.
+type Handler struct {
+	Lexers   map[string]Factory // registered lexers by name
+	MaxBytes int64              // maximum size of the input; DefaultMaxBytes if zero
+	MaxItems int                // maximum number of items; DefaultMaxItems if zero
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.render(w, page{Lexers: h.names(), Lexer: r.URL.Query().Get("lexer")})
+		return
+	case http.MethodPost:
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	max := h.MaxBytes
+	if max <= 0 {
+		max = DefaultMaxBytes
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, max)
+	input, err := readInput(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	name := r.FormValue("lexer")
+	factory, err := h.lookup(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	items, err := h.collect(factory(input))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if !wantsHTML(r) {
+		var buf bytes.Buffer
+		if err := lexer.WriteItems(&buf, items, lexer.FormatJSON); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(buf.Bytes())
+		return
+	}
+	p := page{Lexers: h.names(), Lexer: name, Input: input}
+	for i, item := range items {
+		isErr := item.Err() != nil // formats the message of a lazy error
+		p.Rows = append(p.Rows, row{
+			Index: i,
+			Type:  item.TypeName(),
+			Pos:   item.Position().String(),
+			Value: strconv.Quote(item.Value),
+			Error: isErr,
+		})
+	}
+	h.render(w, p)
+}
+
+// readInput returns the input posted in r.
+func readInput(r *http.Request) (string, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		if err := r.ParseForm(); err != nil {
+			return "", err
+		}
+		return r.PostForm.Get("input"), nil
+	}
+	b, err := io.ReadAll(r.Body)
+	return string(b), err
+}
+
+// lookup returns the factory registered as name.
+func (h *Handler) lookup(name string) (Factory, error) {
+	if name == "" && len(h.Lexers) == 1 {
+		for _, f := range h.Lexers {
+			return f, nil
+		}
+	}
+	f, ok := h.Lexers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown lexer %q", name)
+	}
+	return f, nil
+}
+
+// collect returns the items of l up to, but not including, ItemEOF.
+func (h *Handler) collect(l *lexer.Lexer) ([]*lexer.Item, error) {
+	max := h.MaxItems
+	if max <= 0 {
+		max = DefaultMaxItems
+	}
+	var items []*lexer.Item
+	for item := l.Next(); item.Type != lexer.ItemEOF; item = l.Next() {
+		if len(items) == max {
+			return nil, fmt.Errorf("more than %d items", max)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// names returns the sorted names of the registered lexers.
+func (h *Handler) names() []string {
+	names := make([]string, 0, len(h.Lexers))
+	for name := range h.Lexers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func wantsHTML(r *http.Request) bool {
+	switch r.FormValue("format") {
+	case "html":
+		return true
+	case "json":
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+type page struct {
+	Lexers []string
+	Lexer  string
+	Input  string
+	Rows   []row
+}
+
+type row struct {
+	Index int
+	Type  string
+	Pos   string
+	Value string
+	Error bool
+}
+
+func (h *Handler) render(w http.ResponseWriter, p page) {
+	var buf bytes.Buffer
+	if err := pageTemplate.Execute(&buf, p); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head><title>lexer debug</title></head>
+<body>
+<form method="post">
+<select name="lexer">{{range .Lexers}}<option{{if eq . $.Lexer}} selected{{end}}>{{.}}</option>{{end}}</select>
+<input type="hidden" name="format" value="html">
+<input type="submit" value="Lex">
+<br><textarea name="input" rows="12" cols="80">{{.Input}}</textarea>
+</form>
+{{if .Rows}}<table>
+<tr><th>INDEX</th><th>TYPE</th><th>POS</th><th>VALUE</th></tr>
+{{range .Rows}}<tr{{if .Error}} class="error"{{end}}><td>{{.Index}}</td><td>{{.Type}}</td><td>{{.Pos}}</td><td><code>{{.Value}}</code></td></tr>
+{{end}}</table>{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,94 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexdebug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	lexer "github.com/bmatsuo/go-lexer"
+)
+
+func lexWords(l *lexer.Lexer) lexer.StateFn {
+	l.IgnoreRun(" ")
+	switch {
+	case l.AcceptRun("abcdefghijklmnopqrstuvwxyz") > 0:
+		l.Emit(1)
+		return lexWords
+	case l.Accept("<&"):
+		return l.Errorf("unexpected %q", l.Current())
+	}
+	return nil
+}
+
+var testHandler = &Handler{Lexers: map[string]Factory{
+	"words": func(input string) *lexer.Lexer {
+		return lexer.New(lexWords, input, lexer.WithTypeNames(map[lexer.ItemType]string{1: "word"}))
+	},
+}}
+
+func TestHandlerJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	testHandler.ServeHTTP(w, httptest.NewRequest("POST", "/?lexer=words", strings.NewReader("ab cd <")))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("status %d %q: %s", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+	var items []struct {
+		Type   string `json:"type"`
+		Offset int    `json:"offset"`
+		Value  string `json:"value"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 || items[1].Type != "word" || items[1].Offset != 3 || items[2].Value != `unexpected "<"` {
+		t.Errorf("unexpected items %+v", items)
+	}
+}
+
+func TestHandlerHTML(t *testing.T) {
+	form := url.Values{"input": {"x <"}, "format": {"html"}}
+	r := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	testHandler.ServeHTTP(w, r)
+	body := w.Body.String()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, body)
+	}
+	for _, s := range []string{
+		`<td>word</td><td>1:1</td><td><code>&#34;x&#34;</code></td>`,
+		`<tr class="error">`,
+		`<textarea name="input" rows="12" cols="80">x &lt;</textarea>`,
+	} {
+		if !strings.Contains(body, s) {
+			t.Errorf("missing %s in\n%s", s, body)
+		}
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	for _, test := range []struct {
+		method, target, body string
+		h                    *Handler
+		code                 int
+	}{
+		{"POST", "/?lexer=missing", "", testHandler, http.StatusNotFound},
+		{"PUT", "/", "", testHandler, http.StatusMethodNotAllowed},
+		{"POST", "/", "abcdef", &Handler{Lexers: testHandler.Lexers, MaxBytes: 3}, http.StatusRequestEntityTooLarge},
+		{"POST", "/", "a b c", &Handler{Lexers: testHandler.Lexers, MaxItems: 2}, http.StatusUnprocessableEntity},
+		{"GET", "/", "", testHandler, http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		test.h.ServeHTTP(w, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+		if w.Code != test.code {
+			t.Errorf("%s %s: status %d, want %d", test.method, test.target, w.Code, test.code)
+		}
+	}
+}
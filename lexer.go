@@ -18,19 +18,19 @@ There are some key differences to Pike's presented code.  Next has been renamed
 Advance to be more idiomatic with Backup. Next is used by the parser to
 retrieve items from the lexer.
 
-Two APIs
+# Two APIs
 
 The Lexer type has two APIs, one is used byte StateFn types.  The other is
 called by the parser. These APIs are called the scanner and the parser APIs
 here.
 
-The parser API
+# The parser API
 
 The only function the parser calls on the lexer is Next to retreive the next
 token from the input stream.  Eventually an item with type ItemEOF is returned
 at which point there are no more tokens in the stream.
 
-The scanner API
+# The scanner API
 
 The lexer uses Emit to construct complete lexemes to return from
 future/concurrent calls to Next by the parser.  The scanner uses a combination
@@ -52,12 +52,16 @@ address corner cases.
 package lexer
 
 import (
-	"container/list"
 	"fmt"
+	"io"
 	"math"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 	"unicode"
 	"unicode/utf8"
+	"unsafe"
 )
 
 const EOF rune = 0x04
@@ -79,32 +83,165 @@ type StateFn func(*Lexer) StateFn
 // Lexer contains an input string and state associate with the lexing the
 // input.
 type Lexer struct {
-	input string     // string being scanned
-	start int        // start position for the current lexeme
-	pos   int        // current position
-	width int        // length of the last rune read
-	last  rune       // the last rune read
-	state StateFn    // the current state
-	items *list.List // Buffer of lexed items
+	input string      // string being scanned
+	start int         // start position for the current lexeme
+	pos   int         // current position
+	width int         // length of the last rune read
+	last  rune        // the last rune read
+	state StateFn     // the current state
+	items []*Item     // Buffer of lexed items, items[:head] were consumed
+	head  int         // index in items of the next item for Next
+	base  int         // number of items discarded from the buffer
+	marks []int       // outstanding item marks
+	src   *Source     // input name and line information shared with items
+	data  interface{} // user data for state functions
+	mu    *sync.Mutex // serializes the parser API when non-nil
+
+	collect bool                   // record errors instead of emitting them
+	errs    []error                // errors recorded when collect is true
+	limit   int                    // maximum number of recorded errors, if positive
+	onError func(Position, string) // receives errors out of band, if non-nil
+	halt    bool                   // stop after the current state returns
+
+	hist  []*Item // ring buffer of recently emitted items
+	nhist int     // number of items ever recorded in hist
+
+	retain bool   // append delivered items to all
+	all    []Item // items delivered by Next, if retain is set
+
+	meta     map[string]string // metadata for the next emitted item
+	intern   *Interner         // canonicalizes emitted values if non-nil
+	maxToken int               // longest lexeme that may be emitted, if positive
+
+	stack  []StateFn    // states saved with PushState
+	inputs []savedInput // inputs suspended by PushInput
+
+	modes     Modes    // modes registered with WithModes
+	modeStack []string // names of entered modes, innermost last
+	modeBase  int      // modes at the bottom of modeStack that ExitMode keeps
+
+	semi   *semicolons  // automatic semicolon insertion, if enabled
+	layout *layout      // off-side rule, if enabled
+	memo   *memo        // outcomes of rules run with Memoize, if enabled
+	expect *expectation // input attempted at a position, if enabled
+
+	classes  CharClasses // character classes, UnicodeClasses for nil fields
+	newlines bool        // emit line breaks in ignored text
+	newline  ItemType    // type of line break items
+
+	rec   *Recorder // logs operations if non-nil
+	stats *lexStats // counts for WithMetrics, if non-nil
+	trace *tracing  // notifies a Tracer, if non-nil
+
+	decoder     Decoder       // decodes input if non-nil
+	invalid     InvalidPolicy // treatment of invalid UTF-8
+	nextInvalid int           // offset of the next invalid byte to report
+
+	bidi     bool // warn of bidirectional control characters
+	nextBidi int  // offset following the last bidi control reported
+
+	debug    bool // check invariants
+	backedUp bool // Backup was called since the last rune was read
+
+	slow bool // an option requires Advance and Backup to leave the fast path
+
+	init StateFn  // start state given to New
+	opts []Option // options given to New
 }
 
-// Create a new lexer. Must be given a non-nil state.
-func New(start StateFn, input string) *Lexer {
+// Create a new lexer. Must be given a non-nil state.  Options are applied in
+// order after the lexer is initialized.
+func New(start StateFn, input string, opts ...Option) *Lexer {
 	if start == nil {
 		panic("nil start state")
 	}
-	return &Lexer{
-		state: start,
-		input: input,
-		items: list.New(),
+	l := &Lexer{init: start, opts: opts}
+	l.setup(input)
+	return l
+}
+
+// Reset discards the state of l so that it lexes input from the start state
+// with the options given to New, as if it were returned by New.  Reset reuses
+// the item buffer of l and drops its references to items already emitted,
+// which must not be used after l is reset.
+func (l *Lexer) Reset(input string) {
+	l.release()
+	l.setup(input)
+}
+
+// release discards the state of l, including its references to its input and
+// items, without applying its options.  l must be set up before it is used.
+func (l *Lexer) release() {
+	if l.stats != nil {
+		l.stats.flush(l)
+	}
+	if l.trace != nil {
+		l.trace.endDocument(l)
+	}
+	items := l.items[:cap(l.items)]
+	clear(items)
+	*l = Lexer{init: l.init, opts: l.opts, items: items[:0]}
+}
+
+// setup initializes l to lex input and applies its options.
+func (l *Lexer) setup(input string) {
+	l.state = l.init
+	l.input = input
+	l.src = &Source{text: input}
+	l.hist = make([]*Item, 1)
+	for _, opt := range l.opts {
+		opt(l)
 	}
+	l.setSlow()
 }
 
-// Input returns the input string being lexed by the l.
+// setSlow determines whether any option in effect must observe every rune
+// read, which disables the fast paths of Advance, Backup and AcceptRun.
+func (l *Lexer) setSlow() {
+	l.slow = l.src.transform != nil || l.decoder != nil || l.bidi || l.rec != nil || l.debug
+}
+
+// Input returns the input string being lexed by the l.  While an input pushed
+// with PushInput is being lexed, Input returns the pushed text.
 func (l *Lexer) Input() string {
 	return l.input
 }
 
+// Source returns the Source of l's input.  The Source is shared by all items
+// emitted by l.
+func (l *Lexer) Source() *Source {
+	return l.src
+}
+
+// Position resolves offset in l's input into a Position.
+func (l *Lexer) Position(offset int) Position {
+	return l.src.Position(offset)
+}
+
+// Data returns the user data attached to l with SetData or WithData.
+func (l *Lexer) Data() interface{} {
+	return l.data
+}
+
+// SetData attaches v to l so that it is reachable by state functions.  Data is
+// intended for context shared between states, such as dialect options or
+// nesting counters, and is never inspected by the lexer.
+func (l *Lexer) SetData(v interface{}) {
+	l.data = v
+}
+
+// TypeName returns the name of t given to WithTypeNames.  If t has no name the
+// result of t.String() is returned.
+func (l *Lexer) TypeName(t ItemType) string {
+	return l.src.TypeName(t)
+}
+
+// TypeByName returns the item type with the given name.  Special item types
+// are found by their String names ("EOF", "Error", "Warning").
+func (l *Lexer) TypeByName(name string) (ItemType, bool) {
+	return l.src.LookupType(name)
+}
+
 // Start marks the first byte of item currently being lexed.
 func (l *Lexer) Start() int {
 	return l.start
@@ -119,6 +256,9 @@ func (l *Lexer) Pos() int {
 
 // Current returns the contents of the item currently being lexed.
 func (l *Lexer) Current() string {
+	if l.src.transform != nil {
+		return l.cook(l.start, l.pos)
+	}
 	return l.input[l.start:l.pos]
 }
 
@@ -129,18 +269,60 @@ func (l *Lexer) Last() (r rune, width int) {
 
 // Advance adds one rune of input to the current lexeme, increments the lexer's
 // position, and returns the input rune with its size in bytes (encoded as
-// UTF-8).  Invalid UTF-8 codepoints cause the current call and all subsequent
-// calls to return (utf8.RuneError, 1).  If there is no input the returned size
-// is zero.
+// UTF-8).  An invalid byte of UTF-8 is read as (utf8.RuneError, 1) unless
+// another policy is set with WithInvalidUTF8.  If there is no input the
+// returned size is zero.
+//
+// Reading an ASCII byte touches only local copies of l's position and input,
+// and the unsigned comparison lets the compiler drop the bounds check on the
+// index; every other rune, and every rune read while an option observes the
+// input, is read by advanceSlow.
 func (l *Lexer) Advance() (rune, int) {
-	if l.pos >= len(l.input) {
+	if pos, input := l.pos, l.input; uint(pos) < uint(len(input)) && !l.slow {
+		if c := input[pos]; c < utf8.RuneSelf {
+			l.last, l.width, l.pos = rune(c), 1, pos+1
+			return rune(c), 1
+		}
+	}
+	return l.advanceSlow()
+}
+
+func (l *Lexer) advanceSlow() (rune, int) {
+	c, n := l.advance()
+	if l.bidi && n > 1 {
+		l.checkBidi(l.pos-n, l.pos)
+	}
+	if l.rec != nil {
+		l.rec.advance(l.pos)
+	}
+	l.backedUp = false
+	return c, n
+}
+
+func (l *Lexer) advance() (rune, int) {
+	pos, input := l.pos, l.input
+	if uint(pos) >= uint(len(input)) {
 		l.width = 0
 		return EOF, l.width
 	}
-	l.last, l.width = utf8.DecodeRuneInString(l.input[l.pos:])
-	if l.last == utf8.RuneError && l.width == 1 {
+	if l.src.transform != nil {
+		if r, w, ok := l.advanceTransform(); ok {
+			return r, w
+		}
+	}
+	if l.decoder != nil {
+		return l.advanceDecoder()
+	}
+	if c := input[pos]; c < utf8.RuneSelf {
+		// ASCII fast path avoids the cost of decoding.
+		l.last, l.width = rune(c), 1
+		l.pos++
 		return l.last, l.width
 	}
+	l.last, l.width = utf8.DecodeRuneInString(input[pos:])
+	if l.last == utf8.RuneError && l.width == 1 {
+		return l.advanceInvalid()
+	}
 	l.pos += l.width
 	return l.last, l.width
 }
@@ -149,7 +331,21 @@ func (l *Lexer) Advance() (rune, int) {
 // back in the input string accordingly. Backup should only be called after a
 // call to Advance.
 func (l *Lexer) Backup() {
+	if l.slow {
+		l.backupSlow()
+		return
+	}
+	l.pos -= l.width
+}
+
+func (l *Lexer) backupSlow() {
+	if l.debug {
+		l.checkBackup()
+	}
 	l.pos -= l.width
+	if l.rec != nil {
+		l.rec.record(Op{Kind: OpBackup, Pos: l.pos})
+	}
 }
 
 // Peek returns the next rune in the input stream without adding it to the
@@ -160,17 +356,32 @@ func (l *Lexer) Peek() (rune, int) {
 	return c, n
 }
 
-// Ignore throws away the current lexeme.
+// Ignore throws away the current lexeme.  If l was created with WithNewlines
+// the line breaks in the lexeme are emitted.
 func (l *Lexer) Ignore() {
+	if l.rec != nil {
+		l.rec.record(Op{Kind: OpIgnore, Pos: l.pos, Start: l.start})
+	}
+	if l.newlines {
+		l.emitNewlines()
+	}
 	l.start = l.pos
 }
 
 // Accept advances the lexer if the next rune is in valid.
 func (l *Lexer) Accept(valid string) (ok bool) {
 	r, _ := l.Advance()
-	ok = strings.IndexRune(valid, r) >= 0
+	if 0 <= r && r < utf8.RuneSelf {
+		// An ASCII byte never occurs within the encoding of another rune.
+		ok = strings.IndexByte(valid, byte(r)) >= 0
+	} else {
+		ok = strings.IndexRune(valid, r) >= 0
+	}
 	if !ok {
 		l.Backup()
+		if l.expect != nil {
+			l.expectedRunes(valid)
+		}
 	}
 	return
 }
@@ -181,6 +392,7 @@ func (l *Lexer) AcceptFunc(fn func(rune) bool) (ok bool) {
 	case IsEOF(r, n):
 		return false
 	case IsInvalid(r, n):
+		l.Backup()
 		return false
 	case fn(r):
 		return true
@@ -200,14 +412,82 @@ func (l *Lexer) AcceptRange(tab *unicode.RangeTable) (ok bool) {
 	return
 }
 
+// AcceptFold advances the lexer if the next rune is in valid under Unicode
+// simple case folding.  For example, AcceptFold("ß") accepts 'ẞ' as well.
+func (l *Lexer) AcceptFold(valid string) bool {
+	return l.AcceptFunc(func(r rune) bool {
+		return foldAny(r, func(c rune) bool { return strings.IndexRune(valid, c) >= 0 })
+	})
+}
+
+// AcceptRangeFold advances the lexer if the next rune is in tab under Unicode
+// simple case folding.
+func (l *Lexer) AcceptRangeFold(tab *unicode.RangeTable) bool {
+	return l.AcceptFunc(func(r rune) bool {
+		return foldAny(r, func(c rune) bool { return unicode.Is(tab, c) })
+	})
+}
+
+// foldAny returns true if fn returns true for any rune in the case folding
+// orbit of r, including r itself.
+func foldAny(r rune, fn func(rune) bool) bool {
+	if fn(r) {
+		return true
+	}
+	for c := unicode.SimpleFold(r); c != r; c = unicode.SimpleFold(c) {
+		if fn(c) {
+			return true
+		}
+	}
+	return false
+}
+
 // AcceptRun advances l's position as long as the current rune is in valid.
 func (l *Lexer) AcceptRun(valid string) (n int) {
+	if !l.slow {
+		// Consume the leading ASCII runes of the run without calling Advance.
+		pos, input := l.pos, l.input
+		var last byte
+		for uint(pos) < uint(len(input)) {
+			c := input[pos]
+			if c >= utf8.RuneSelf || strings.IndexByte(valid, c) < 0 {
+				break
+			}
+			last = c
+			pos++
+		}
+		if n = pos - l.pos; n > 0 {
+			l.last, l.width, l.pos = rune(last), 1, pos
+		}
+	}
 	for l.Accept(valid) {
 		n++
 	}
 	return
 }
 
+// AcceptRunMax advances l's position as long as the current rune is in valid,
+// up to at most max runes.  AcceptRunMax returns the number of runes
+// advanced.
+func (l *Lexer) AcceptRunMax(valid string, max int) (n int) {
+	for n < max && l.Accept(valid) {
+		n++
+	}
+	return
+}
+
+// AcceptCount advances l's position n runes if the next n runes are all in
+// valid.  Otherwise l's position is unchanged.  AcceptCount returns true if l
+// advanced.
+func (l *Lexer) AcceptCount(valid string, n int) bool {
+	m := l.mark()
+	if l.AcceptRunMax(valid, n) == n {
+		return true
+	}
+	l.reset(m)
+	return false
+}
+
 // AcceptRunFunc advances l's position as long as fn returns true for the next
 // input rune.
 func (l *Lexer) AcceptRunFunc(fn func(rune) bool) int {
@@ -230,59 +510,658 @@ func (l *Lexer) AcceptRunRange(tab *unicode.RangeTable) (n int) {
 // s. AcceptString returns true if l advanced.
 func (l *Lexer) AcceptString(s string) (ok bool) {
 	if strings.HasPrefix(l.input[l.pos:], s) {
+		if l.bidi {
+			l.checkBidi(l.pos, l.pos+len(s))
+		}
 		l.pos += len(s)
+		l.jumped()
 		return true
 	}
+	if l.expect != nil {
+		l.expected(strconv.Quote(s))
+	}
 	return false
 }
 
+// IgnoreRun advances l's position as long as the current rune is in valid and
+// throws away the current lexeme.  IgnoreRun returns the number of runes
+// advanced.
+func (l *Lexer) IgnoreRun(valid string) int {
+	n := l.AcceptRun(valid)
+	l.Ignore()
+	return n
+}
+
+// IgnoreUntil advances l's position until the next rune is in stop or input is
+// consumed, then throws away the current lexeme.  The stopping rune is not
+// consumed.  IgnoreUntil returns the number of runes advanced.
+func (l *Lexer) IgnoreUntil(stop string) int {
+	n := l.AcceptRunFunc(func(r rune) bool {
+		return strings.IndexRune(stop, r) < 0
+	})
+	l.Ignore()
+	return n
+}
+
+// IgnoreWhile advances l's position as long as fn returns true for the next
+// input rune and throws away the current lexeme.  IgnoreWhile returns the
+// number of runes advanced.
+func (l *Lexer) IgnoreWhile(fn func(rune) bool) int {
+	n := l.AcceptRunFunc(fn)
+	l.Ignore()
+	return n
+}
+
+// A scanMark records the scanner's position so it can be restored.
+type scanMark struct {
+	pos   int
+	width int
+	last  rune
+}
+
+func (l *Lexer) mark() scanMark {
+	return scanMark{l.pos, l.width, l.last}
+}
+
+func (l *Lexer) reset(m scanMark) {
+	l.pos, l.width, l.last = m.pos, m.width, m.last
+	l.jumped()
+}
+
+// jumped records a change of position made without Advance or Backup.
+func (l *Lexer) jumped() {
+	l.backedUp = false
+	if l.rec != nil {
+		l.rec.record(Op{Kind: OpJump, Pos: l.pos, N: l.width})
+	}
+}
+
+// skip advances l's position n bytes and records the last rune skipped so
+// that Backup and Last behave as though the bytes were read with Advance.
+func (l *Lexer) skip(n int) {
+	if n <= 0 {
+		return
+	}
+	l.last, l.width = utf8.DecodeLastRuneInString(l.input[l.pos : l.pos+n])
+	if l.bidi {
+		l.checkBidi(l.pos, l.pos+n)
+	}
+	l.pos += n
+	l.jumped()
+}
+
+// AcceptRunNotAny advances l's position until the next rune is in stops or the
+// input is consumed.  Unlike other AcceptRun methods AcceptRunNotAny returns
+// the number of bytes, not runes, advanced.  The search is performed with
+// strings.IndexAny and is much faster than advancing one rune at a time.
+func (l *Lexer) AcceptRunNotAny(stops string) int {
+	n := strings.IndexAny(l.input[l.pos:], stops)
+	if n < 0 {
+		n = len(l.input) - l.pos
+	}
+	l.skip(n)
+	return n
+}
+
+// AcceptRunNotFunc advances l's position until stop returns true for the next
+// rune or the input is consumed.  AcceptRunNotFunc returns the number of bytes
+// advanced.  The search is performed with strings.IndexFunc.
+func (l *Lexer) AcceptRunNotFunc(stop func(rune) bool) int {
+	n := strings.IndexFunc(l.input[l.pos:], stop)
+	if n < 0 {
+		n = len(l.input) - l.pos
+	}
+	l.skip(n)
+	return n
+}
+
+// AdvanceTo advances l's position to the next occurrence of b, which is not
+// consumed, adding the bytes in between to the current lexeme.  If b does not
+// occur in the remaining input l advances to the end of the input and
+// AdvanceTo returns false.  b should be an ASCII byte so that it cannot match
+// part of a multi-byte rune.
+func (l *Lexer) AdvanceTo(b byte) bool {
+	i := strings.IndexByte(l.input[l.pos:], b)
+	if i < 0 {
+		l.skip(len(l.input) - l.pos)
+		return false
+	}
+	l.skip(i)
+	return true
+}
+
+// SetPos moves l to offset, which may be before or after its position, and
+// discards the current lexeme so that lexing continues at offset, as when a
+// format refers to data elsewhere in the input.  Last reports no rune until
+// the next is read, so Backup must not be called before then.  SetPos panics
+// if offset is outside of l's input.
+func (l *Lexer) SetPos(offset int) {
+	if offset < 0 || offset > len(l.input) {
+		panic(fmt.Sprintf("lexer: position %d outside of input [0, %d]", offset, len(l.input)))
+	}
+	l.pos, l.start = offset, offset
+	l.last, l.width = EOF, 0
+	l.jumped()
+}
+
+// SkipUntilByte advances l's position to the next occurrence of b as with
+// AdvanceTo and throws away the current lexeme.
+func (l *Lexer) SkipUntilByte(b byte) bool {
+	ok := l.AdvanceTo(b)
+	l.Ignore()
+	return ok
+}
+
+// PushState saves fn on l's state stack so that it can be resumed later by
+// PopState.  The stack lets a state function enter a sub-language, such as an
+// expression embedded in a string, and return to the enclosing context
+// without knowing what it was.
+func (l *Lexer) PushState(fn StateFn) {
+	l.stack = append(l.stack, fn)
+}
+
+// PopState removes and returns the state most recently saved with PushState.
+// PopState returns nil if the stack is empty.
+func (l *Lexer) PopState() StateFn {
+	if len(l.stack) == 0 {
+		return nil
+	}
+	fn := l.stack[len(l.stack)-1]
+	l.stack[len(l.stack)-1] = nil
+	l.stack = l.stack[:len(l.stack)-1]
+	return fn
+}
+
 // Errorf causes an error item to be emitted from l.Next().  The item's value
 // (and its error message) are the result of evaluating format and vs with
-// fmt.Sprintf.
+// fmt.Errorf.  Errors wrapped with the %w verb, such as ErrUnexpectedEOF, can
+// be found in the item's error with errors.Is.
+//
+// If l was created with WithErrorCollection the error is recorded instead of
+// being emitted, and a state function may continue lexing by ignoring the
+// returned StateFn.  If l was created with WithErrorHandler the handler
+// receives the error instead of the parser.
 func (l *Lexer) Errorf(format string, vs ...interface{}) StateFn {
-	l.enqueue(&Item{
-		ItemError,
-		l.start,
-		fmt.Sprintf(format, vs...),
-	})
+	l.error(l.errorf(ItemError, format, vs...))
 	return nil
 }
 
+// errorf returns an item of type t at the start of the current lexeme for
+// the error formatted by fmt.Errorf.
+func (l *Lexer) errorf(t ItemType, format string, vs ...interface{}) *Item {
+	err := fmt.Errorf(format, vs...)
+	i := &Item{Type: t, Pos: l.start, Value: err.Error()}
+	switch err.(type) {
+	case wrapper, multiWrapper:
+		i.cause = err
+	}
+	return i
+}
+
+// LazyErrorf is like Errorf but defers formatting the message until the
+// error is inspected with String or Err, or through the methods of Error, so
+// errors that are discarded, as during speculative lexing, cost no call to
+// fmt.  The values vs are retained until then and should not be modified.
+// The Value of the item is empty until its message is formatted.
+func (l *Lexer) LazyErrorf(format string, vs ...interface{}) StateFn {
+	l.error(l.lazyErrorf(ItemError, format, vs))
+	return nil
+}
+
+// LazyWarnf is like Warnf but defers formatting the message as LazyErrorf
+// does.
+func (l *Lexer) LazyWarnf(format string, vs ...interface{}) {
+	i := l.lazyErrorf(ItemWarning, format, vs)
+	if l.rec != nil {
+		i.format()
+		l.rec.record(Op{Kind: OpError, Pos: i.Pos, Type: i.Type, Text: i.Value})
+	}
+	l.enqueue(i)
+}
+
+// lazyErrorf returns an item of type t at the start of the current lexeme
+// whose message is formatted from format and vs when it is first needed.
+func (l *Lexer) lazyErrorf(t ItemType, format string, vs []interface{}) *Item {
+	return &Item{Type: t, Pos: l.start, lazy: &lazyMessage{format, vs}}
+}
+
+// A lazyMessage is the format and arguments of an unformatted error message.
+type lazyMessage struct {
+	format string
+	vs     []interface{}
+}
+
+// format formats the message of an item created by lazyErrorf.
+func (i *Item) format() {
+	if i.lazy == nil {
+		return
+	}
+	err := fmt.Errorf(i.lazy.format, i.lazy.vs...)
+	i.lazy = nil
+	i.Value = err.Error()
+	switch err.(type) {
+	case wrapper, multiWrapper:
+		i.cause = err
+	}
+}
+
+// errorAt returns an error at offset pos that is not emitted.
+func (l *Lexer) errorAt(pos int, msg string) *Error {
+	return l.wrapAt(pos, msg, nil)
+}
+
+// Errors returns the errors recorded by l when it was created with the
+// WithErrorCollection option.  The list is complete once l.Next() has
+// returned ItemEOF.
+func (l *Lexer) Errors() []error {
+	return l.errs
+}
+
+func (l *Lexer) error(i *Item) {
+	if l.stats != nil {
+		l.stats.errors++
+	}
+	if l.trace != nil {
+		l.trace.doc.Errors++
+	}
+	if l.rec != nil {
+		i.format()
+		l.rec.record(Op{Kind: OpError, Pos: i.Pos, Type: i.Type, Text: i.Value})
+	}
+	if l.onError != nil && !l.halt {
+		i.format()
+		l.onError(l.src.Position(i.Pos), i.Value)
+		if !l.collect {
+			return
+		}
+	}
+	if !l.collect {
+		l.enqueue(i)
+		return
+	}
+	if l.halt {
+		return
+	}
+	i.src = l.src
+	l.errs = append(l.errs, (*Error)(i))
+	if l.limit > 0 && len(l.errs) >= l.limit {
+		l.enqueue(&Item{Type: ItemError, Pos: i.Pos, Value: "too many errors"})
+		l.halt = true
+	}
+}
+
+// Warnf causes a warning item to be emitted from l.Next().  Unlike Errorf, a
+// warning does not stop the lexer and the calling state function continues.
+// The item's value is the result of evaluating format and vs with
+// fmt.Errorf, so as with Errorf errors wrapped with the %w verb can be found
+// in the item's error with errors.Is.
+func (l *Lexer) Warnf(format string, vs ...interface{}) {
+	i := l.errorf(ItemWarning, format, vs...)
+	if l.rec != nil {
+		l.rec.record(Op{Kind: OpError, Pos: i.Pos, Type: i.Type, Text: i.Value})
+	}
+	l.enqueue(i)
+}
+
 // Emit the current value as an Item with the specified type.
 func (l *Lexer) Emit(t ItemType) {
-	l.enqueue(&Item{
-		t,
-		l.start,
-		l.input[l.start:l.pos],
-	})
+	l.emit(&Item{Type: t})
+}
+
+// EmitWith emits the current value as an Item with the specified type and
+// payload v, typically a value computed while scanning the lexeme such as a
+// parsed number.  Use PayloadOf to retrieve the payload with its type.
+func (l *Lexer) EmitWith(t ItemType, v interface{}) {
+	l.emit(&Item{Type: t, Payload: v})
+}
+
+// EmitAttrs emits the current value as an Item with the specified type and
+// attributes.
+func (l *Lexer) EmitAttrs(t ItemType, a Attrs) {
+	l.emit(&Item{Type: t, Attrs: a})
+}
+
+// EmitPart emits the first n bytes of the current lexeme as an Item of type
+// t and leaves the rest as the current lexeme, so that a state function that
+// scanned too far, such as ">>" where the grammar needs two ">" items, can
+// split the lexeme without backing up and scanning it again.  EmitPart panics
+// unless 0 < n < len(l.Current()); n should fall on a rune boundary.
+func (l *Lexer) EmitPart(n int, t ItemType) {
+	if n <= 0 || n >= l.pos-l.start {
+		panic(fmt.Sprintf("lexer: part of %d bytes of a %d byte lexeme", n, l.pos-l.start))
+	}
+	m := l.mark()
+	l.reset(scanMark{pos: l.start + n})
+	l.Emit(t)
+	l.reset(m)
+}
+
+// EmitMarker emits an Item of type t with an empty value at l's current
+// position, marking a structural boundary that does not consume input.  The
+// current lexeme is not disturbed: Current returns the same text before and
+// after EmitMarker, and the next call to Emit includes it.  Metadata staged
+// with SetMeta is left for the next emitted lexeme.
+//
+// A marker emitted in the middle of a lexeme is queued before the item for
+// that lexeme even though its position is greater.
+func (l *Lexer) EmitMarker(t ItemType) {
+	if l.rec != nil {
+		l.rec.record(Op{Kind: OpMarker, Pos: l.pos, Type: t})
+	}
+	l.enqueue(&Item{Type: t, Pos: l.pos})
+}
+
+// InjectItem enqueues a copy of item that does not correspond to input text,
+// such as an inserted semicolon, an implicit closing token at the end of the
+// input, or a placeholder for a missing token during error recovery.  The
+// copy's position is l's current position and its Synthetic method reports
+// true.  The current lexeme is not disturbed.
+func (l *Lexer) InjectItem(item Item) {
+	if l.rec != nil {
+		l.rec.record(Op{Kind: OpInject, Pos: l.pos, Type: item.Type, Text: item.Value})
+	}
+	item.Pos = l.pos
+	item.end = l.pos
+	item.synthetic = true
+	l.enqueue(&item)
+}
+
+// emit completes i with the current lexeme and enqueues it.
+func (l *Lexer) emit(i *Item) {
+	if l.debug {
+		l.checkEmit(i.Type)
+	}
+	if l.maxToken > 0 && l.pos-l.start > l.maxToken {
+		msg := fmt.Sprintf("token of %d bytes exceeds the limit of %d", l.pos-l.start, l.maxToken)
+		l.error((*Item)(l.wrapAt(l.start, msg, ErrTokenTooLong)))
+		l.meta = nil
+		l.start = l.pos
+		return
+	}
+	if l.rec != nil {
+		l.rec.record(Op{Kind: OpEmit, Pos: l.pos, Start: l.start, Type: i.Type})
+	}
+	i.Pos = l.start
+	i.Value = l.Current()
+	i.end = l.pos
+	if l.intern != nil {
+		i.Value = l.intern.Intern(i.Value)
+	}
+	i.Meta = l.meta
+	l.enqueue(i)
+	l.meta = nil
 	l.start = l.pos
 }
 
 // The method by which items are extracted from the input.
 // Returns nil if the lexer has entered a nil state.
+//
+// Next is safe for concurrent use if l was created with the WithSync option.
 func (l *Lexer) Next() (i *Item) {
-	for {
-		if head := l.dequeue(); head != nil {
-			return head
+	if l.mu != nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	return l.next()
+}
+
+func (l *Lexer) next() *Item {
+	if !l.fill() {
+		l.finish()
+		return l.eof()
+	}
+	return l.dequeue()
+}
+
+// finish records the end of l's input once l has stopped, whether it was
+// driven by Next, NextBudget or Step.
+func (l *Lexer) finish() {
+	if l.stats != nil {
+		l.stats.flush(l)
+	}
+	if l.trace != nil {
+		l.trace.endDocument(l)
+	}
+}
+
+// PeekItem returns the item that the next call to Next will return, running
+// state functions as necessary, without consuming it.
+//
+// PeekItem is safe for concurrent use if l was created with the WithSync
+// option.
+func (l *Lexer) PeekItem() *Item {
+	if l.mu != nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	if !l.fill() {
+		return l.eof()
+	}
+	return l.items[l.head]
+}
+
+// SplitItem splits the item that the next call to Next will return into an
+// item of type first holding the first n bytes of its value, which Next then
+// returns, and an item of type rest holding the remainder, which follows it.
+// SplitItem lets a parser split an operator that the lexer scanned whole when
+// the grammar needs its parts, such as the ">>" closing two template argument
+// lists.  The payload, attributes and metadata of the item are dropped.
+//
+// SplitItem returns false and leaves the item unchanged if the item is
+// ItemEOF, an error, synthetic, or scanned through a Transform, if n is not
+// within its value, or if an ItemMark refers to an item after it.
+//
+// SplitItem is safe for concurrent use if l was created with the WithSync
+// option.
+func (l *Lexer) SplitItem(n int, first, rest ItemType) bool {
+	if l.mu != nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	if !l.fill() {
+		return false
+	}
+	i := l.items[l.head]
+	if i.Err() != nil || i.synthetic || i.End() != i.Pos+len(i.Value) || n <= 0 || n >= len(i.Value) {
+		return false
+	}
+	idx := l.base + l.head
+	for _, m := range l.marks {
+		if m > idx {
+			return false
 		}
+	}
+	a := &Item{Type: first, Pos: i.Pos, Value: i.Value[:n], src: i.src, end: i.Pos + n}
+	b := &Item{Type: rest, Pos: i.Pos + n, Value: i.Value[n:], src: i.src, end: i.End()}
+	l.items = append(l.items, nil)
+	copy(l.items[l.head+1:], l.items[l.head:])
+	l.items[l.head], l.items[l.head+1] = a, b
+	if l.retain && idx < len(l.all) {
+		l.all = append(l.all, Item{})
+		copy(l.all[idx+1:], l.all[idx:])
+		l.all[idx], l.all[idx+1] = *a, *b
+	}
+	return true
+}
+
+// fill runs state functions until an item is queued or l has stopped.  fill
+// returns false if no item is queued.
+func (l *Lexer) fill() bool {
+	for l.head == len(l.items) {
 		if l.state == nil {
-			return &Item{ItemEOF, l.start, ""}
+			return l.atEOF()
 		}
+		l.runState()
+	}
+	return true
+}
+
+// runState calls the current state function of l.
+func (l *Lexer) runState() {
+	if l.stats != nil && l.stats.begin.IsZero() {
+		l.stats.begin = time.Now()
+	}
+	if l.rec != nil {
+		l.rec.record(Op{Kind: OpState, Pos: l.pos, Text: stateName(l.state)})
+	}
+	if l.trace != nil {
+		st := l.trace.beginState(l)
+		l.state = l.state(l)
+		l.trace.endState(l, st)
+	} else {
 		l.state = l.state(l)
 	}
-	panic("unreachable")
+	if l.debug {
+		l.checkPos()
+	}
+	if l.halt {
+		l.state = nil
+	}
+}
+
+// atEOF inserts the synthetic items due at the end of the input and returns
+// true if any were inserted.
+func (l *Lexer) atEOF() bool {
+	semi := l.semi != nil && l.semi.atEOF(l)
+	layout := l.layout != nil && l.layout.atEOF(l)
+	return semi || layout
+}
+
+func (l *Lexer) eof() *Item {
+	return &Item{Type: ItemEOF, Pos: l.start, src: l.src}
 }
 
 func (l *Lexer) enqueue(i *Item) {
-	l.items.PushBack(i)
+	i.src = l.src
+	if l.semi != nil {
+		l.semi.before(l, i)
+	}
+	if l.layout != nil {
+		l.layout.before(l, i)
+	}
+	l.push(i)
+}
+
+// push appends i to the item queue and the history.
+func (l *Lexer) push(i *Item) {
+	l.items = append(l.items, i)
+	if l.stats != nil {
+		l.stats.items[i.Type]++
+	}
+	if len(l.hist) > 0 {
+		l.hist[l.nhist%len(l.hist)] = i
+		l.nhist++
+	}
+}
+
+// LastEmitted returns the kth most recently emitted item, where k = 0 is the
+// last item emitted.  By default only the last item is kept, WithHistory
+// retains more.  LastEmitted returns nil if fewer than k+1 items have been
+// emitted or if the kth item is no longer retained.
+//
+// LastEmitted is intended for state functions whose rules depend on the
+// preceding tokens.  The returned items must not be modified.
+func (l *Lexer) LastEmitted(k int) *Item {
+	if k < 0 || k >= len(l.hist) || k >= l.nhist {
+		return nil
+	}
+	return l.hist[(l.nhist-1-k)%len(l.hist)]
 }
 
 func (l *Lexer) dequeue() *Item {
-	head := l.items.Front()
-	if head == nil {
+	if l.head == len(l.items) {
 		return nil
 	}
-	return l.items.Remove(head).(*Item)
+	i := l.items[l.head]
+	if l.retain && l.base+l.head == len(l.all) {
+		l.all = append(l.all, *i)
+	}
+	l.head++
+	l.discard()
+	return i
+}
+
+// ItemsAll returns a copy of every item delivered by Next, in order and
+// excluding ItemEOF, if l was created with WithItemsAll.  An item delivered
+// again after ResetItems appears once.  The items share one backing array,
+// and the slice is complete once Next has returned ItemEOF.
+func (l *Lexer) ItemsAll() []Item {
+	return l.all
+}
+
+// discard drops consumed items from the buffer that are not retained by a
+// mark.  The buffer is only compacted once at least half of it can be dropped.
+func (l *Lexer) discard() {
+	keep := l.head
+	for _, m := range l.marks {
+		if m-l.base < keep {
+			keep = m - l.base
+		}
+	}
+	if keep == 0 || keep < len(l.items)-keep {
+		return
+	}
+	n := copy(l.items, l.items[keep:])
+	for i := n; i < len(l.items); i++ {
+		l.items[i] = nil
+	}
+	l.items = l.items[:n]
+	l.head -= keep
+	l.base += keep
+}
+
+// An ItemMark identifies a position in the stream of items returned by Next.
+type ItemMark int
+
+// MarkItems returns a mark for the item that the next call to Next will
+// return.  Items consumed after the mark is made are retained until the mark
+// is released, so that ResetItems can replay them without lexing the input
+// again.  Every mark must eventually be released with ReleaseItems.
+func (l *Lexer) MarkItems() ItemMark {
+	if l.mu != nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	m := l.base + l.head
+	l.marks = append(l.marks, m)
+	return ItemMark(m)
+}
+
+// ResetItems causes Next to return items again starting at mark, which must
+// not have been released.  The mark remains valid for further resets.
+func (l *Lexer) ResetItems(mark ItemMark) {
+	if l.mu != nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	if l.markIndex(mark) < 0 {
+		panic("lexer: reset with an invalid item mark")
+	}
+	l.head = int(mark) - l.base
+}
+
+// ReleaseItems releases mark.  Items buffered only on behalf of mark are
+// discarded once consumed.
+func (l *Lexer) ReleaseItems(mark ItemMark) {
+	if l.mu != nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	i := l.markIndex(mark)
+	if i < 0 {
+		panic("lexer: release of an invalid item mark")
+	}
+	l.marks = append(l.marks[:i], l.marks[i+1:]...)
+	l.discard()
+}
+
+func (l *Lexer) markIndex(mark ItemMark) int {
+	for i, m := range l.marks {
+		if m == int(mark) {
+			return i
+		}
+	}
+	return -1
 }
 
 // A type for all the types of items in the language being lexed.
@@ -292,18 +1171,110 @@ type ItemType uint16
 const (
 	ItemEOF ItemType = math.MaxUint16 - iota
 	ItemError
+	ItemWarning
 )
 
+// String returns the name of a special item type, or "ItemType(n)" for other
+// item types.
+func (t ItemType) String() string {
+	switch t {
+	case ItemEOF:
+		return "EOF"
+	case ItemError:
+		return "Error"
+	case ItemWarning:
+		return "Warning"
+	}
+	return fmt.Sprintf("ItemType(%d)", uint16(t))
+}
+
 // An individual scanned item (a lexeme).
 type Item struct {
-	Type  ItemType
-	Pos   int
-	Value string
+	Type      ItemType
+	Pos       int
+	Value     string
+	Payload   interface{}       // value attached with EmitWith, if any
+	Attrs     Attrs             // attributes attached with EmitAttrs
+	Meta      map[string]string // metadata attached with SetMeta, if any
+	Namespace string            // sub-language that produced the item, set by Delegate
+	src       *Source
+	end       int          // offset following the lexeme in the original input
+	synthetic bool         // inserted with InjectItem
+	cause     error        // error wrapped by an error item
+	lazy      *lazyMessage // unformatted message of an error item
+}
+
+// End returns the offset of the first byte following i.  For an item emitted
+// through a Transform, End is an offset in the original input and may differ
+// from i.Pos+len(i.Value).  A synthetic item ends where it begins.
+func (i *Item) End() int {
+	if i.end > i.Pos || i.synthetic {
+		return i.end
+	}
+	return i.Pos + len(i.Value)
+}
+
+// Bytes returns the value of i as a byte slice sharing memory with the
+// value, so that byte-oriented consumers such as hashes and writers avoid
+// copying it.  The slice must not be modified.  Bytes returns nil for an empty
+// value.
+func (i *Item) Bytes() []byte {
+	if i.Value == "" {
+		return nil
+	}
+	return unsafe.Slice(unsafe.StringData(i.Value), len(i.Value))
+}
+
+// WriteTo writes the value of i to w without converting it to a byte slice
+// if w implements io.StringWriter.
+func (i *Item) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, i.Value)
+	return int64(n), err
 }
 
-// Err returns the error corresponding to i, if one exists.
+// Synthetic returns true if i was inserted with InjectItem rather than
+// scanned from the input.
+func (i *Item) Synthetic() bool {
+	return i.synthetic
+}
+
+// TypeName returns the name of i's type given to WithTypeNames.  If the type
+// has no name the result of i.Type.String() is returned.
+func (i *Item) TypeName() string {
+	if i.src == nil {
+		return i.Type.String()
+	}
+	return i.src.TypeName(i.Type)
+}
+
+// PayloadOf returns the payload of i if it has type V.  Otherwise PayloadOf
+// returns the zero value of V and false.
+func PayloadOf[V any](i *Item) (V, bool) {
+	v, ok := i.Payload.(V)
+	return v, ok
+}
+
+// Source returns the Source that i was scanned from, or nil if i was not
+// emitted by a Lexer.
+func (i *Item) Source() *Source {
+	return i.src
+}
+
+// Position resolves i.Pos into a Position.  If i has no Source only the
+// Offset of the returned Position is set.
+func (i *Item) Position() Position {
+	if i.src == nil {
+		return Position{Offset: i.Pos}
+	}
+	return i.src.Position(i.Pos)
+}
+
+// Err returns the error corresponding to i, if one exists.  Both ItemError
+// and ItemWarning items have errors, the Severity method of the returned
+// *Error distinguishes them.
 func (i *Item) Err() error {
-	if i.Type == ItemError {
+	if i.Type == ItemError || i.Type == ItemWarning {
+		i.format()
 		return (*Error)(i)
 	}
 	return nil
@@ -312,7 +1283,8 @@ func (i *Item) Err() error {
 // String returns the raw lexeme of i.
 func (i *Item) String() string {
 	switch i.Type {
-	case ItemError:
+	case ItemError, ItemWarning:
+		i.format()
 		return i.Value
 	case ItemEOF:
 		return "EOF"
@@ -323,9 +1295,98 @@ func (i *Item) String() string {
 	return i.Value
 }
 
-// Error is an item of type ItemError
+// Error is an item of type ItemError or ItemWarning
 type Error Item
 
+// Severity returns SeverityWarning if err is a warning and SeverityError
+// otherwise.
+func (err *Error) Severity() Severity {
+	if err.Type == ItemWarning {
+		return SeverityWarning
+	}
+	return SeverityError
+}
+
+// Error returns the error message with the position of err and an excerpt
+// of the input there, in the form
+//
+//	file:line:col: message (offset N, near "excerpt")
+//
+// The filename is omitted if the source of err has none.  If err was not
+// emitted by a Lexer only its message and offset are included.
 func (err *Error) Error() string {
-	return (*Item)(err).String()
+	msg := (*Item)(err).String()
+	if err.src == nil {
+		return fmt.Sprintf("%s (offset %d)", msg, err.Pos)
+	}
+	var near string
+	switch ex := err.Excerpt(); {
+	case ex != "":
+		near = fmt.Sprintf("near %q", ex)
+	case err.Pos >= len(err.src.text):
+		near = "at end of input"
+	default:
+		near = "at end of line"
+	}
+	return fmt.Sprintf("%v: %s (offset %d, %s)", err.Position(), msg, err.Pos, near)
+}
+
+// Unwrap returns the error wrapped by err, if any.
+func (err *Error) Unwrap() error {
+	(*Item)(err).format()
+	return err.cause
+}
+
+// Message returns the message of err without its position.
+func (err *Error) Message() string {
+	(*Item)(err).format()
+	return err.Value
+}
+
+// Position resolves the offset of err into a Position.
+func (err *Error) Position() Position {
+	return (*Item)(err).Position()
+}
+
+// maxExcerpt is the maximum length in bytes of an excerpt.
+const maxExcerpt = 16
+
+// Excerpt returns a short excerpt of the input beginning at the offset of err
+// and ending before the next line break.  Excerpt returns an empty string if
+// err is at the end of its input or was not emitted by a Lexer.
+func (err *Error) Excerpt() string {
+	if err.src == nil || err.Pos < 0 || err.Pos >= len(err.src.text) {
+		return ""
+	}
+	s := err.src.text[err.Pos:]
+	if i := strings.IndexAny(s, "\r\n"); i >= 0 {
+		s = s[:i]
+	}
+	if len(s) > maxExcerpt {
+		n := maxExcerpt
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		s = s[:n]
+	}
+	return s
+}
+
+// Severity distinguishes fatal errors from warnings.
+type Severity uint8
+
+// Severities of an Error.
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	}
+	return fmt.Sprintf("Severity(%d)", uint8(s))
 }
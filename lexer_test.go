@@ -11,11 +11,790 @@ package lexer
  */
 
 import (
-    "testing"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
 )
 
-
 func TestLexer(t *testing.T) {
 
 }
 
+func TestLexerData(t *testing.T) {
+	type counter struct{ n int }
+	var start StateFn
+	start = func(l *Lexer) StateFn {
+		if l.AcceptRun("(") == 0 {
+			return nil
+		}
+		l.Data().(*counter).n++
+		return start
+	}
+	c := new(counter)
+	l := New(start, "((", WithData(c))
+	l.Next()
+	if c.n != 1 {
+		t.Errorf("got %d want 1", c.n)
+	}
+	l.SetData(nil)
+	if l.Data() != nil {
+		t.Errorf("unexpected data %v", l.Data())
+	}
+}
+
+func TestLexerSync(t *testing.T) {
+	const n = 1000
+	var start StateFn
+	start = func(l *Lexer) StateFn {
+		if l.Accept("x") {
+			l.Emit(0)
+			return start
+		}
+		return nil
+	}
+	l := New(start, strings.Repeat("x", n), WithSync())
+	counts := make(chan int)
+	for g := 0; g < 4; g++ {
+		go func() {
+			var c, last int
+			for item := l.Next(); item.Type != ItemEOF; item = l.Next() {
+				if item.Pos < last {
+					t.Errorf("item at %d received after item at %d", item.Pos, last)
+				}
+				last = item.Pos
+				c++
+			}
+			counts <- c
+		}()
+	}
+	var total int
+	for g := 0; g < 4; g++ {
+		total += <-counts
+	}
+	if total != n {
+		t.Errorf("received %d items; want %d", total, n)
+	}
+}
+
+func TestLexerWarnf(t *testing.T) {
+	start := func(l *Lexer) StateFn {
+		if l.AcceptString(`"\q`) {
+			l.Warnf("unknown escape")
+		}
+		l.AcceptRun(`q"`)
+		l.Emit(0)
+		return l.Errorf("stop")
+	}
+	l := New(start, `"\qq"`)
+	for _, want := range []struct {
+		typ ItemType
+		sev Severity
+	}{{ItemWarning, SeverityWarning}, {0, 0}, {ItemError, SeverityError}} {
+		item := l.Next()
+		if item.Type != want.typ {
+			t.Fatalf("got %v (type %d) want type %d", item, item.Type, want.typ)
+		}
+		err := item.Err()
+		if want.typ == 0 {
+			if err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			continue
+		}
+		if sev := err.(*Error).Severity(); sev != want.sev {
+			t.Errorf("got severity %v want %v", sev, want.sev)
+		}
+	}
+}
+
+type countStringer int
+
+func (c *countStringer) String() string {
+	*c++
+	return "arg"
+}
+
+func TestLexerLazyErrorf(t *testing.T) {
+	var calls countStringer
+	l := New(func(l *Lexer) StateFn {
+		l.LazyWarnf("warning %v", &calls)
+		return l.LazyErrorf("error %v: %w", &calls, ErrUnexpectedEOF)
+	}, "abc")
+	warn := l.Next()
+	item := l.Next()
+	if calls != 0 {
+		t.Fatalf("formatted %d messages before inspection", calls)
+	}
+	if item.Value != "" {
+		t.Errorf("unformatted value: %q", item.Value)
+	}
+	if s := item.String(); s != "error arg: lexer: unexpected EOF" {
+		t.Errorf("message: %q", s)
+	}
+	if !errors.Is(item.Err(), ErrUnexpectedEOF) {
+		t.Errorf("error does not wrap ErrUnexpectedEOF")
+	}
+	if msg := warn.Err().(*Error).Message(); msg != "warning arg" {
+		t.Errorf("warning: %q", msg)
+	}
+	if calls != 2 {
+		t.Errorf("formatted %d times", calls)
+	}
+}
+
+func TestLexerErrorCollection(t *testing.T) {
+	var start StateFn
+	start = func(l *Lexer) StateFn {
+		switch c, n := l.Advance(); {
+		case IsEOF(c, n):
+			return nil
+		case c == 'x':
+			l.Emit(0)
+		default:
+			l.Errorf("unexpected %q", c)
+			l.Ignore()
+		}
+		return start
+	}
+	l := New(start, "x?x!", WithErrorCollection())
+	var n int
+	for item := l.Next(); item.Type != ItemEOF; item = l.Next() {
+		if item.Err() != nil {
+			t.Fatalf("unexpected error item %v", item)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("got %d items want 2", n)
+	}
+	errs := l.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors want 2: %v", len(errs), errs)
+	}
+	if errs[1].(*Error).Pos != 3 {
+		t.Errorf("second error at %d want 3", errs[1].(*Error).Pos)
+	}
+}
+
+func TestLexerErrorHandler(t *testing.T) {
+	var start StateFn
+	start = func(l *Lexer) StateFn {
+		switch c, n := l.Advance(); {
+		case IsEOF(c, n):
+			return nil
+		case c == 'x':
+			l.Emit(0)
+		default:
+			l.Errorf("unexpected %q", c)
+			l.Ignore()
+		}
+		return start
+	}
+	var msgs []string
+	handler := func(pos Position, msg string) {
+		msgs = append(msgs, fmt.Sprintf("%d:%d: %s", pos.Line, pos.Column, msg))
+	}
+	l := New(start, "x?\nx!", WithErrorHandler(handler))
+	var n int
+	for item := l.Next(); item.Type != ItemEOF; item = l.Next() {
+		if item.Err() != nil {
+			t.Fatalf("unexpected error item %v", item)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("got %d items want 2", n)
+	}
+	want := "1:2: unexpected '?'|1:3: unexpected '\\n'|2:2: unexpected '!'"
+	if got := strings.Join(msgs, "|"); got != want {
+		t.Errorf("errors %q, want %q", got, want)
+	}
+}
+
+func TestLexerErrorLimit(t *testing.T) {
+	var start StateFn
+	start = func(l *Lexer) StateFn {
+		if _, n := l.Advance(); n == 0 {
+			return nil
+		}
+		l.Errorf("bad")
+		l.Ignore()
+		return start
+	}
+	l := New(start, strings.Repeat("?", 100), WithErrorCollection(), WithErrorLimit(3))
+	item := l.Next()
+	if item.Type != ItemError || item.Value != "too many errors" || item.Pos != 2 {
+		t.Errorf("unexpected item %v (pos %d)", item, item.Pos)
+	}
+	if item = l.Next(); item.Type != ItemEOF {
+		t.Errorf("unexpected item %v", item)
+	}
+	if n := len(l.Errors()); n != 3 {
+		t.Errorf("got %d errors want 3", n)
+	}
+}
+
+func TestLexerIgnore(t *testing.T) {
+	start := func(l *Lexer) StateFn {
+		if n := l.IgnoreRun(" \t"); n != 2 {
+			t.Errorf("IgnoreRun: got %d want 2", n)
+		}
+		l.AcceptString("#")
+		if n := l.IgnoreUntil("\n"); n != 8 {
+			t.Errorf("IgnoreUntil: got %d want 8", n)
+		}
+		l.Accept("\n")
+		if n := l.IgnoreWhile(unicode.IsSpace); n != 1 {
+			t.Errorf("IgnoreWhile: got %d want 1", n)
+		}
+		l.AcceptRun("abc")
+		l.Emit(0)
+		return nil
+	}
+	item := New(start, " \t# comment\n abc").Next()
+	if item.Value != "abc" || item.Pos != 13 {
+		t.Errorf("unexpected item %q (pos %d)", item.Value, item.Pos)
+	}
+}
+
+func TestLexerAcceptCount(t *testing.T) {
+	const hex = "0123456789abcdefABCDEF"
+	l := New(func(*Lexer) StateFn { return nil }, "0777x00ef12")
+	if n := l.AcceptRunMax("01234567", 3); n != 3 || l.Pos() != 3 {
+		t.Errorf("AcceptRunMax: got %d (pos %d) want 3", n, l.Pos())
+	}
+	l.AcceptRun("7")
+	if l.AcceptCount("x", 2) || l.Pos() != 4 {
+		t.Errorf("AcceptCount: advanced on partial match (pos %d)", l.Pos())
+	}
+	l.Accept("x")
+	if !l.AcceptCount(hex, 4) || l.Current() != "0777x00ef" {
+		t.Errorf("AcceptCount: got %q", l.Current())
+	}
+}
+
+func TestLexerAcceptFold(t *testing.T) {
+	kelvin := string(rune(0x212A))
+	l := New(func(*Lexer) StateFn { return nil }, "FfΣς"+kelvin)
+	if !l.AcceptFold("f") || !l.AcceptFold("f") {
+		t.Errorf("AcceptFold: ASCII letters not folded")
+	}
+	if !l.AcceptFold("σ") || !l.AcceptFold("σ") {
+		t.Errorf("AcceptFold: sigma not folded")
+	}
+	k := &unicode.RangeTable{R16: []unicode.Range16{{'k', 'k', 1}}}
+	if !l.AcceptRangeFold(k) {
+		t.Errorf("AcceptRangeFold: Kelvin sign not folded")
+	}
+	if l.AcceptFold("x") {
+		t.Errorf("AcceptFold: accepted EOF")
+	}
+}
+
+func TestLexerLastEmitted(t *testing.T) {
+	var start StateFn
+	start = func(l *Lexer) StateFn {
+		if l.AcceptRun("abc") == 0 {
+			return nil
+		}
+		l.Emit(0)
+		l.Accept(" ")
+		l.Ignore()
+		return start
+	}
+	l := New(start, "a b c", WithHistory(2))
+	for l.Next().Type != ItemEOF {
+	}
+	if item := l.LastEmitted(0); item == nil || item.Value != "c" {
+		t.Errorf("LastEmitted(0): got %v", item)
+	}
+	if item := l.LastEmitted(1); item == nil || item.Value != "b" {
+		t.Errorf("LastEmitted(1): got %v", item)
+	}
+	if item := l.LastEmitted(2); item != nil {
+		t.Errorf("LastEmitted(2): got %v", item)
+	}
+	defer func() {
+		if e := recover(); e != "lexer: negative history length -1" {
+			t.Errorf("unexpected panic %v", e)
+		}
+	}()
+	New(start, "", WithHistory(-1))
+}
+
+func TestLexerPeekItem(t *testing.T) {
+	var start StateFn
+	start = func(l *Lexer) StateFn {
+		if !l.Accept("ab") {
+			return nil
+		}
+		l.Emit(0)
+		return start
+	}
+	l := New(start, "ab")
+	peek := l.PeekItem()
+	if peek.Value != "a" || l.PeekItem() != peek {
+		t.Errorf("unexpected peek %v", peek)
+	}
+	if item := l.Next(); item != peek {
+		t.Errorf("Next returned %v after peeking %v", item, peek)
+	}
+	if item := l.Next(); item.Value != "b" {
+		t.Errorf("unexpected item %v", item)
+	}
+	if peek = l.PeekItem(); peek.Type != ItemEOF {
+		t.Errorf("unexpected peek %v", peek)
+	}
+}
+
+func TestLexerMarkItems(t *testing.T) {
+	var start StateFn
+	states := 0
+	start = func(l *Lexer) StateFn {
+		states++
+		if !l.Accept("abcd") {
+			return nil
+		}
+		l.Emit(0)
+		return start
+	}
+	l := New(start, "abcd")
+	values := func(n int) (s string) {
+		for i := 0; i < n; i++ {
+			s += l.Next().Value
+		}
+		return s
+	}
+	values(1)
+	outer := l.MarkItems()
+	if v := values(2); v != "bc" {
+		t.Fatalf("got %q", v)
+	}
+	inner := l.MarkItems()
+	values(1)
+	l.ResetItems(outer)
+	if v := values(3); v != "bcd" {
+		t.Errorf("after reset got %q", v)
+	}
+	l.ReleaseItems(outer)
+	l.ResetItems(inner)
+	if v := values(1); v != "d" {
+		t.Errorf("after inner reset got %q", v)
+	}
+	l.ReleaseItems(inner)
+	if item := l.Next(); item.Type != ItemEOF {
+		t.Errorf("unexpected item %v", item)
+	}
+	if states != 5 {
+		t.Errorf("input lexed again: %d states", states)
+	}
+	if len(l.items) != 0 {
+		t.Errorf("%d items retained", len(l.items))
+	}
+}
+
+func TestLexerItemsAll(t *testing.T) {
+	var start StateFn
+	start = func(l *Lexer) StateFn {
+		s := l.Save()
+		if l.AcceptRun("ab") > 0 {
+			l.Emit(1)
+			l.Restore(s) // a discarded alternative
+		}
+		if !l.Accept("abcd") {
+			return nil
+		}
+		l.Emit(0)
+		return start
+	}
+	l := New(start, "abcd", WithItemsAll())
+	l.Next()
+	mark := l.MarkItems()
+	l.Next()
+	l.Next()
+	l.ResetItems(mark)
+	l.ReleaseItems(mark)
+	Collect(l)
+	var values string
+	for _, item := range l.ItemsAll() {
+		if item.Type != 0 {
+			t.Errorf("unexpected item %v", item)
+		}
+		values += item.Value
+	}
+	if values != "abcd" {
+		t.Errorf("got %q", values)
+	}
+	l = New(start, "ab")
+	Collect(l)
+	if items := l.ItemsAll(); items != nil {
+		t.Errorf("items retained without WithItemsAll: %v", items)
+	}
+}
+
+func TestLexerEmitWith(t *testing.T) {
+	start := func(l *Lexer) StateFn {
+		l.AcceptRun("0123456789")
+		n, _ := strconv.Atoi(l.Current())
+		l.EmitWith(0, n)
+		return nil
+	}
+	item := New(start, "42").Next()
+	if n, ok := PayloadOf[int](item); !ok || n != 42 {
+		t.Errorf("got payload %v %v", n, ok)
+	}
+	if _, ok := PayloadOf[string](item); ok {
+		t.Errorf("payload has the wrong type")
+	}
+}
+
+func benchmarkAdvance(b *testing.B, input string) {
+	l := New(func(*Lexer) StateFn { return nil }, input)
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.pos = 0
+		for {
+			if _, n := l.Advance(); n == 0 {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkAdvanceASCII(b *testing.B) {
+	benchmarkAdvance(b, strings.Repeat("func main() { return x + 1 }\n", 100))
+}
+
+func BenchmarkAdvanceUnicode(b *testing.B) {
+	benchmarkAdvance(b, strings.Repeat("λ x → x + 1 ∘ ƒ\n", 100))
+}
+
+var benchWords = strings.Repeat("func main() { return x1 + 10 * y }\n", 100)
+
+func lexBenchWords(l *Lexer) StateFn {
+	l.IgnoreRun(" \t\n")
+	switch {
+	case l.AcceptRun("abcdefghijklmnopqrstuvwxyz0123456789") > 0:
+		l.Emit(1)
+	default:
+		if _, n := l.Advance(); n == 0 {
+			return nil
+		}
+		l.Emit(2)
+	}
+	return lexBenchWords
+}
+
+func BenchmarkLexWords(b *testing.B) {
+	b.SetBytes(int64(len(benchWords)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l := New(lexBenchWords, benchWords, WithHistory(0))
+		for l.Next().Type != ItemEOF {
+		}
+	}
+}
+
+// BenchmarkHandWrittenWords lexes the input of BenchmarkLexWords with a
+// hand-written loop, as a baseline for the cost of the Lexer.
+func BenchmarkHandWrittenWords(b *testing.B) {
+	b.SetBytes(int64(len(benchWords)))
+	var n int
+	for i := 0; i < b.N; i++ {
+		input := benchWords
+		for pos := 0; pos < len(input); {
+			switch c := input[pos]; {
+			case c == ' ' || c == '\t' || c == '\n':
+				pos++
+			case 'a' <= c && c <= 'z' || '0' <= c && c <= '9':
+				start := pos
+				for pos < len(input) && ('a' <= input[pos] && input[pos] <= 'z' || '0' <= input[pos] && input[pos] <= '9') {
+					pos++
+				}
+				n += len(input[start:pos])
+			default:
+				pos++
+				n++
+			}
+		}
+	}
+	if n == 0 {
+		b.Fatal("no tokens")
+	}
+}
+
+func TestLexerAdvanceTo(t *testing.T) {
+	l := New(func(*Lexer) StateFn { return nil }, "# cømment\n\"quoted")
+	if !l.SkipUntilByte('\n') || l.Pos() != 10 || l.Start() != 10 {
+		t.Errorf("SkipUntilByte: pos %d start %d", l.Pos(), l.Start())
+	}
+	l.AcceptString("\n\"")
+	if l.AdvanceTo('"') {
+		t.Errorf("AdvanceTo: found missing byte")
+	}
+	if l.Current() != "\n\"quoted" {
+		t.Errorf("AdvanceTo: got %q", l.Current())
+	}
+	l.Backup()
+	if r, _ := l.Peek(); r != 'd' {
+		t.Errorf("AdvanceTo: backed up to %q", r)
+	}
+}
+
+func TestLexerSetPos(t *testing.T) {
+	// The input ends with the offset of its first field.
+	const input = "name=x;4"
+	var start StateFn
+	start = func(l *Lexer) StateFn {
+		l.SetPos(len(input) - 1)
+		l.Advance()
+		off := int(l.Current()[0] - '0')
+		l.SetPos(off)
+		if r, n := l.Last(); r != EOF || n != 0 {
+			t.Errorf("last rune %q %d after SetPos", r, n)
+		}
+		l.AcceptRunNotAny(";")
+		l.Emit(1)
+		return nil
+	}
+	rec := new(Recorder)
+	items := Collect(New(start, input, WithRecorder(rec), WithInvariants()))
+	if len(items) != 1 || items[0].Value != "=x" || items[0].Pos != 4 {
+		t.Fatalf("unexpected items %v", items)
+	}
+	replayed, err := Replay(input, rec.Ops())
+	if err != nil || len(replayed) != 1 || replayed[0].Pos != 4 {
+		t.Errorf("replayed %v: %v", replayed, err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("no panic for a position outside of the input")
+		}
+	}()
+	New(start, input).SetPos(len(input) + 1)
+}
+
+func TestLexerRegion(t *testing.T) {
+	const doc = "<p>\nab cd</p>"
+	lo, hi := strings.Index(doc, "ab"), strings.Index(doc, "</p>")
+	l := New(lexRecorded, doc, WithRegion(lo, hi))
+	items := Collect(l)
+	if len(items) != 2 || items[0].Value != "ab" || items[1].Value != "cd" || items[1].Pos != 7 {
+		t.Fatalf("unexpected items %v", items)
+	}
+	if pos := items[1].Position(); pos.Line != 2 || pos.Column != 4 {
+		t.Errorf("position %v", pos)
+	}
+	if eof := l.Next(); eof.Type != ItemEOF || eof.Pos != hi {
+		t.Errorf("unexpected end %v at %d", eof, eof.Pos)
+	}
+}
+
+func TestLexerAcceptRunNot(t *testing.T) {
+	l := New(func(*Lexer) StateFn { return nil }, "héllo {{ x }} wörld")
+	if n := l.AcceptRunNotAny("{}"); n != 7 || l.Current() != "héllo " {
+		t.Errorf("AcceptRunNotAny: got %d %q", n, l.Current())
+	}
+	l.Ignore()
+	if n := l.AcceptRunNotFunc(unicode.IsLetter); n != 3 || l.Current() != "{{ " {
+		t.Errorf("AcceptRunNotFunc: got %d %q", n, l.Current())
+	}
+	l.Ignore()
+	l.AcceptRunNotFunc(func(r rune) bool { return r == 'ö' })
+	if n := l.AcceptRunNotAny("?"); n != 5 || l.Current() != "x }} wörld" {
+		t.Errorf("AcceptRunNotAny: got %d %q", n, l.Current())
+	}
+}
+
+func TestLexerAcceptRunFast(t *testing.T) {
+	// WithInvariants disables the fast paths of Advance and AcceptRun.
+	for _, input := range []string{"", "abc", "abc+", "abé", "ab\xffc", "+ab"} {
+		fast := New(func(*Lexer) StateFn { return nil }, input)
+		slow := New(func(*Lexer) StateFn { return nil }, input, WithInvariants())
+		for _, l := range []*Lexer{fast, slow} {
+			l.AcceptRun("abcé")
+			l.Accept("+")
+		}
+		fr, fw := fast.Last()
+		sr, sw := slow.Last()
+		if fast.Current() != slow.Current() || fr != sr || fw != sw {
+			t.Errorf("%q: fast %q %q %d; slow %q %q %d", input,
+				fast.Current(), fr, fw, slow.Current(), sr, sw)
+		}
+	}
+}
+
+func TestLexerEmitPart(t *testing.T) {
+	const input = "a>>=b"
+	start := func(l *Lexer) StateFn {
+		l.AcceptRun("ab")
+		l.Emit(1)
+		l.AcceptRun(">=")
+		r, _ := l.Last()
+		l.EmitPart(1, 2)
+		l.EmitPart(1, 2)
+		if last, _ := l.Last(); last != r {
+			t.Errorf("last rune %q, want %q", last, r)
+		}
+		l.Emit(3)
+		l.AcceptRun("ab")
+		l.Emit(1)
+		return nil
+	}
+	rec := new(Recorder)
+	items := Collect(New(start, input, WithRecorder(rec), WithInvariants()))
+	want := []string{"a", ">", ">", "=", "b"}
+	if len(items) != len(want) {
+		t.Fatalf("unexpected items %v", items)
+	}
+	for i, item := range items {
+		if item.Value != want[i] || item.Pos != i || item.End() != i+1 {
+			t.Errorf("item %d: %q at [%d,%d)", i, item.Value, item.Pos, item.End())
+		}
+	}
+	if replayed, err := Replay(input, rec.Ops()); err != nil || len(replayed) != len(want) {
+		t.Errorf("replayed %v: %v", replayed, err)
+	}
+}
+
+func TestLexerSplitItem(t *testing.T) {
+	const (
+		itemName ItemType = iota
+		itemLess
+		itemGreater
+		itemShift
+	)
+	start := func(l *Lexer) StateFn {
+		for {
+			switch {
+			case l.AcceptRun("abcdefghijklmnopqrstuvwxyz") > 0:
+				l.Emit(itemName)
+			case l.AcceptString("<"):
+				l.Emit(itemLess)
+			case l.AcceptString(">>"):
+				l.Emit(itemShift)
+			case l.AcceptString(">"):
+				l.Emit(itemGreater)
+			default:
+				return nil
+			}
+		}
+	}
+	// A parser expecting the end of an argument list splits ">>".
+	l := New(start, "a<b<c>>", WithItemsAll())
+	var types []ItemType
+	depth := 0
+	for item := l.Next(); item.Type != ItemEOF; item = l.Next() {
+		switch item.Type {
+		case itemLess:
+			depth++
+		case itemGreater:
+			depth--
+		}
+		types = append(types, item.Type)
+		if next := l.PeekItem(); next.Type == itemShift && depth > 0 && !l.SplitItem(1, itemGreater, itemGreater) {
+			t.Fatalf("split of %v failed", next)
+		}
+	}
+	want := []ItemType{itemName, itemLess, itemName, itemLess, itemName, itemGreater, itemGreater}
+	if fmt.Sprint(types) != fmt.Sprint(want) {
+		t.Errorf("types %v, want %v", types, want)
+	}
+	all := l.ItemsAll()
+	if last := all[len(all)-1]; last.Value != ">" || last.Pos != 6 || last.End() != 7 {
+		t.Errorf("last item %q at [%d,%d)", last.Value, last.Pos, last.End())
+	}
+	l = New(start, ">")
+	if l.SplitItem(1, itemGreater, itemGreater) {
+		t.Errorf("split of a single byte item")
+	}
+}
+
+func TestItemBytes(t *testing.T) {
+	item := &Item{Value: "abc"}
+	if b := item.Bytes(); string(b) != "abc" || cap(b) != 3 {
+		t.Errorf("bytes %q cap %d", b, cap(b))
+	}
+	if b := (&Item{}).Bytes(); b != nil {
+		t.Errorf("bytes of an empty value %q", b)
+	}
+	var buf bytes.Buffer
+	if n, err := item.WriteTo(&buf); n != 3 || err != nil || buf.String() != "abc" {
+		t.Errorf("wrote %d %q: %v", n, buf.String(), err)
+	}
+	if n := testing.AllocsPerRun(10, func() { item.Bytes() }); n != 0 {
+		t.Errorf("%v allocations", n)
+	}
+}
+
+func TestLexerEmitMarker(t *testing.T) {
+	l := New(func(*Lexer) StateFn { return nil }, "ab")
+	l.Advance()
+	l.EmitMarker(2)
+	if l.Current() != "a" || l.Start() != 0 {
+		t.Errorf("EmitMarker: current %q start %d", l.Current(), l.Start())
+	}
+	l.Advance()
+	l.Emit(1)
+	marker, item := l.Next(), l.Next()
+	if marker.Type != 2 || marker.Pos != 1 || marker.Value != "" || marker.End() != 1 {
+		t.Errorf("EmitMarker: marker %v at %d %q", marker.Type, marker.Pos, marker.Value)
+	}
+	if item.Type != 1 || item.Value != "ab" {
+		t.Errorf("EmitMarker: item %v %q", item.Type, item.Value)
+	}
+}
+
+func TestLexerInjectItem(t *testing.T) {
+	l := New(func(*Lexer) StateFn { return nil }, "ab")
+	l.Advance()
+	l.InjectItem(Item{Type: 2, Pos: 10, Value: ";"})
+	l.Advance()
+	l.Emit(1)
+	injected, item := l.Next(), l.Next()
+	if injected.Type != 2 || injected.Pos != 1 || injected.End() != 1 || !injected.Synthetic() {
+		t.Errorf("InjectItem: %v at %d-%d synthetic %v", injected.Type, injected.Pos, injected.End(), injected.Synthetic())
+	}
+	if item.Value != "ab" || item.Synthetic() {
+		t.Errorf("InjectItem: item %q synthetic %v", item.Value, item.Synthetic())
+	}
+}
+
+func TestErrorExcerpt(t *testing.T) {
+	start := func(l *Lexer) StateFn {
+		l.AcceptRun("x")
+		l.Ignore()
+		return l.Errorf("bad")
+	}
+	for _, test := range []struct {
+		input   string
+		excerpt string
+		msg     string
+	}{
+		{"xxabcdefghijklmnopqrstuvwxyz", "abcdefghijklmnop", `1:3: bad (offset 2, near "abcdefghijklmnop")`},
+		{"xab\ncd", "ab", `1:2: bad (offset 1, near "ab")`},
+		{"x\nxxab", "", "1:2: bad (offset 1, at end of line)"},
+		{"xa" + strings.Repeat("é", 10), "a" + strings.Repeat("é", 7), `1:2: bad (offset 1, near "aééééééé")`},
+		{"xx", "", "1:3: bad (offset 2, at end of input)"},
+	} {
+		err := New(start, test.input).Next().Err().(*Error)
+		if ex := err.Excerpt(); ex != test.excerpt {
+			t.Errorf("%q: excerpt %q, want %q", test.input, ex, test.excerpt)
+		}
+		if msg := err.Error(); msg != test.msg {
+			t.Errorf("%q: message %q, want %q", test.input, msg, test.msg)
+		}
+		if err.Message() != "bad" {
+			t.Errorf("%q: Message returned %q", test.input, err.Message())
+		}
+	}
+	err := &Error{Type: ItemError, Pos: 4, Value: "detached"}
+	if msg := err.Error(); msg != "detached (offset 4)" {
+		t.Errorf("detached error message %q", msg)
+	}
+}
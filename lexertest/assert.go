@@ -0,0 +1,126 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexertest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"text/tabwriter"
+
+	lexer "github.com/bmatsuo/go-lexer"
+)
+
+// A Want is an item expected by AssertItems.  The type of an item is always
+// compared, its value unless AnyValue is set, and its line and column only if
+// they are positive.
+type Want struct {
+	Type     lexer.ItemType
+	Value    string
+	Line     int
+	Column   int
+	AnyValue bool
+}
+
+// AssertItems lexes l to completion and reports a test failure through t if
+// its items, excluding ItemEOF, differ from want.  The failure names the
+// first divergent index, shows where it is in the input, and lists the items
+// got and wanted up to that point with the type names of l.  At most
+// len(want)+1 items are read from l, so a lexer that does not terminate is
+// reported rather than hanging the test.  AssertItems returns true if the
+// items matched.
+func AssertItems(t testing.TB, l *lexer.Lexer, want []Want) bool {
+	t.Helper()
+	var got []*lexer.Item
+	for len(got) <= len(want) {
+		item := l.Next()
+		if item.Type == lexer.ItemEOF {
+			break
+		}
+		got = append(got, item)
+	}
+	for i := 0; i < len(got) || i < len(want); i++ {
+		if i < len(got) && i < len(want) && matches(got[i], want[i]) {
+			continue
+		}
+		t.Errorf("%s", itemDiff(l.Source(), got, want, i))
+		return false
+	}
+	return true
+}
+
+func matches(item *lexer.Item, w Want) bool {
+	if item.Type != w.Type || !w.AnyValue && itemValue(item) != w.Value {
+		return false
+	}
+	pos := item.Position()
+	return (w.Line <= 0 || pos.Line == w.Line) && (w.Column <= 0 || pos.Column == w.Column)
+}
+
+// itemDiff describes the divergence of got and want at index i.
+func itemDiff(src *lexer.Source, got []*lexer.Item, want []Want, i int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "items differ at index %d: ", i)
+	off := len(src.Text())
+	switch {
+	case i >= len(got):
+		fmt.Fprintf(&b, "missing %s", formatWant(src, want[i]))
+	case i >= len(want):
+		fmt.Fprintf(&b, "unexpected %s", formatItem(got[i]))
+		off = got[i].Pos
+	default:
+		fmt.Fprintf(&b, "got %s, want %s", formatItem(got[i]), formatWant(src, want[i]))
+		off = got[i].Pos
+	}
+	b.WriteByte('\n')
+	b.WriteString(context(src.Text(), off))
+	b.WriteString("\n\n")
+	tw := tabwriter.NewWriter(&b, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tINDEX\tGOT\tWANT")
+	for k := 0; k <= i; k++ {
+		var g, w string
+		if k < len(got) {
+			g = formatItem(got[k])
+		}
+		if k < len(want) {
+			w = formatWant(src, want[k])
+		}
+		mark := ""
+		if k == i {
+			mark = ">"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", mark, k, g, w)
+	}
+	tw.Flush()
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func formatItem(item *lexer.Item) string {
+	return fmt.Sprintf("%s %s at %v", item.TypeName(), strconv.Quote(itemValue(item)), item.Position())
+}
+
+// itemValue returns the value of item, formatting the message of a lazy
+// error.
+func itemValue(item *lexer.Item) string {
+	item.Err()
+	return item.Value
+}
+
+func formatWant(src *lexer.Source, w Want) string {
+	s := src.TypeName(w.Type)
+	if !w.AnyValue {
+		s += " " + strconv.Quote(w.Value)
+	}
+	switch {
+	case w.Line > 0 && w.Column > 0:
+		s += fmt.Sprintf(" at %d:%d", w.Line, w.Column)
+	case w.Line > 0:
+		s += fmt.Sprintf(" on line %d", w.Line)
+	case w.Column > 0:
+		s += fmt.Sprintf(" at column %d", w.Column)
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexertest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	lexer "github.com/bmatsuo/go-lexer"
+)
+
+// failT records the failures reported to it instead of failing the test.
+type failT struct {
+	*testing.T
+	failures []string
+}
+
+func (t *failT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func TestAssertItems(t *testing.T) {
+	l := lexer.New(lexTokens, "abc 12\nxy", lexer.WithTypeNames(typeNames))
+	AssertItems(t, l, []Want{
+		{Type: itemIdent, Value: "abc", Line: 1},
+		{Type: itemInt, Value: "12", Column: 5},
+		{Type: itemIdent, Value: "xy", Line: 2, Column: 1},
+	})
+}
+
+func TestAssertItemsDiff(t *testing.T) {
+	ft := &failT{T: t}
+	l := lexer.New(lexTokens, "abc 12\nxy", lexer.WithTypeNames(typeNames))
+	ok := AssertItems(ft, l, []Want{
+		{Type: itemIdent, Value: "abc"},
+		{Type: itemInt, Value: "12"},
+		{Type: itemInt, Value: "xy", Line: 2},
+	})
+	if ok || len(ft.failures) != 1 {
+		t.Fatalf("ok %v, failures %q", ok, ft.failures)
+	}
+	want := `items differ at index 2: got Ident "xy" at 2:1, want Int "xy" on line 2
+	xy
+	^
+
+   INDEX  GOT                 WANT
+   0      Ident "abc" at 1:1  Ident "abc"
+   1      Int "12" at 1:5     Int "12"
+>  2      Ident "xy" at 2:1   Int "xy" on line 2`
+	if ft.failures[0] != want {
+		t.Errorf("failure:\n%s\nwant:\n%s", ft.failures[0], want)
+	}
+
+	ft.failures = nil
+	l = lexer.New(lexTokens, "abc ?", lexer.WithTypeNames(typeNames))
+	AssertItems(ft, l, []Want{{Type: itemIdent, Value: "abc"}})
+	if len(ft.failures) != 1 || !strings.HasPrefix(ft.failures[0], `items differ at index 1: unexpected Error "unexpected input" at 1:5`) {
+		t.Errorf("unexpected failures %q", ft.failures)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexertest
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	lexer "github.com/bmatsuo/go-lexer"
+)
+
+// Default budget of a Corpus.
+const (
+	DefaultTimeout  = 10 * time.Second
+	DefaultMaxItems = 1 << 20
+)
+
+// A Corpus is a directory tree of sample inputs, such as testdata, that a
+// lexer must lex without error.
+type Corpus struct {
+	Dir      string                 // root of the tree
+	Match    func(path string) bool // lexes only the files matched, if non-nil
+	Timeout  time.Duration          // time budget per file; DefaultTimeout if zero
+	MaxItems int                    // item budget per file; DefaultMaxItems if zero
+}
+
+// Run runs a subtest for each regular file in c, named by its path relative
+// to c.Dir, that lexes the file with a lexer returned by newLexer and fails
+// as described by Check.
+func (c Corpus) Run(t *testing.T, newLexer func(input string) *lexer.Lexer) {
+	t.Helper()
+	err := filepath.WalkDir(c.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() || c.Match != nil && !c.Match(path) {
+			return err
+		}
+		name, err := filepath.Rel(c.Dir, path)
+		if err != nil {
+			return err
+		}
+		t.Run(filepath.ToSlash(name), func(t *testing.T) {
+			if err := c.Check(path, newLexer); err != nil {
+				t.Error(err)
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Check lexes the file at path with a lexer returned by newLexer and returns
+// an error naming the file and offset if the lexer emits an error item,
+// panics, or does not emit ItemEOF within the budget of c.  A state function
+// that never returns cannot be interrupted and hangs Check.
+func (c Corpus) Check(path string, newLexer func(input string) *lexer.Lexer) (err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	timeout, max := c.Timeout, c.MaxItems
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if max <= 0 {
+		max = DefaultMaxItems
+	}
+	l := newLexer(string(b))
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("%s: offset %d: panic: %v", path, l.Pos(), v)
+		}
+	}()
+	deadline := time.Now().Add(timeout)
+	for n := 0; ; n++ {
+		if n == max {
+			return fmt.Errorf("%s: offset %d: more than %d items", path, l.Pos(), max)
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("%s: offset %d: no end of input after %v", path, l.Pos(), timeout)
+		}
+		item, ready := l.NextBudget(lexer.Budget{Time: remaining})
+		if !ready {
+			return fmt.Errorf("%s: offset %d: no end of input after %v", path, l.Pos(), timeout)
+		}
+		switch item.Type {
+		case lexer.ItemEOF:
+			return nil
+		case lexer.ItemError:
+			return fmt.Errorf("%s: %v", path, item.Err())
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexertest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	lexer "github.com/bmatsuo/go-lexer"
+)
+
+func writeCorpus(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, text := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCorpusRun(t *testing.T) {
+	dir := writeCorpus(t, map[string]string{
+		"a.txt":     "abc 123",
+		"sub/b.txt": "x\ny\n",
+		"skip.bin":  "?",
+	})
+	c := Corpus{Dir: dir, Match: func(path string) bool { return strings.HasSuffix(path, ".txt") }}
+	c.Run(t, newTestLexer)
+}
+
+func TestCorpusCheck(t *testing.T) {
+	dir := writeCorpus(t, map[string]string{
+		"error.txt": "abc ?",
+		"panic.txt": "abc !",
+		"loop.txt":  "abc",
+		"many.txt":  "a b c",
+	})
+	var bang lexer.StateFn
+	bang = func(l *lexer.Lexer) lexer.StateFn {
+		if l.AcceptRun("abc") == 0 {
+			l.IgnoreRun(" ")
+			panic("bang")
+		}
+		return bang
+	}
+	panics := func(input string) *lexer.Lexer { return lexer.New(bang, input) }
+	var loop lexer.StateFn
+	loop = func(l *lexer.Lexer) lexer.StateFn { return loop }
+	loops := func(input string) *lexer.Lexer { return lexer.New(loop, input) }
+	for _, test := range []struct {
+		file     string
+		c        Corpus
+		newLexer func(string) *lexer.Lexer
+		err      string
+	}{
+		{"error.txt", Corpus{}, newTestLexer, "error.txt: 1:5: unexpected input"},
+		{"panic.txt", Corpus{}, panics, "panic.txt: offset 4: panic: bang"},
+		{"loop.txt", Corpus{Timeout: time.Millisecond}, loops, "loop.txt: offset 0: no end of input after 1ms"},
+		{"many.txt", Corpus{MaxItems: 2}, newTestLexer, "many.txt: offset 3: more than 2 items"},
+	} {
+		err := test.c.Check(filepath.Join(dir, test.file), test.newLexer)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want %q", test.file, err, test.err)
+		}
+	}
+}
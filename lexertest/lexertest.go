@@ -0,0 +1,225 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lexertest provides utilities for testing lexers.  AssertItems checks
+// the items of a lexer against expected items, RunSpecs runs a table of
+// inputs with their expected items written compactly, and Corpus lexes a tree
+// of sample files looking for errors.  Diff compares a lexer against a
+// reference tokenizer, such as text/scanner or a regular expression, and
+// reports the first token at which they disagree.
+package lexertest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/scanner"
+	"unicode/utf8"
+
+	lexer "github.com/bmatsuo/go-lexer"
+)
+
+// A Token is a token in a form that tokenizers with different types can agree
+// on.
+type Token struct {
+	Kind   string
+	Text   string
+	Offset int
+}
+
+func (tok Token) String() string {
+	return fmt.Sprintf("%s %q at offset %d", tok.Kind, tok.Text, tok.Offset)
+}
+
+// A Tokenizer splits input into tokens.
+type Tokenizer func(input string) ([]Token, error)
+
+// Lexer returns a Tokenizer that collects the items of a lexer starting in
+// start.  The kind of each token is given by kind, or by Item.TypeName if kind
+// is nil.  Items for which kind returns an empty string are skipped, and an
+// error item is returned as an error.
+func Lexer(start lexer.StateFn, kind func(*lexer.Item) string, opts ...lexer.Option) Tokenizer {
+	if kind == nil {
+		kind = (*lexer.Item).TypeName
+	}
+	return func(input string) ([]Token, error) {
+		var toks []Token
+		l := lexer.New(start, input, opts...)
+		for {
+			i := l.Next()
+			switch i.Type {
+			case lexer.ItemEOF:
+				return toks, nil
+			case lexer.ItemError:
+				return toks, i.Err()
+			}
+			if k := kind(i); k != "" {
+				toks = append(toks, Token{Kind: k, Text: i.Value, Offset: i.Pos})
+			}
+		}
+	}
+}
+
+// Scanner returns a Tokenizer that uses a text/scanner.Scanner configured
+// with mode.  Token kinds are those returned by scanner.TokenString, for
+// example "Ident" or `"+"`.
+func Scanner(mode uint) Tokenizer {
+	return func(input string) ([]Token, error) {
+		var s scanner.Scanner
+		var errs []string
+		s.Init(strings.NewReader(input))
+		s.Mode = mode
+		s.Error = func(s *scanner.Scanner, msg string) {
+			errs = append(errs, fmt.Sprintf("%v: %s", s.Position, msg))
+		}
+		var toks []Token
+		for tok := s.Scan(); tok != scanner.EOF; tok = s.Scan() {
+			toks = append(toks, Token{Kind: scanner.TokenString(tok), Text: s.TokenText(), Offset: s.Position.Offset})
+		}
+		if len(errs) > 0 {
+			return toks, fmt.Errorf("%s", errs[0])
+		}
+		return toks, nil
+	}
+}
+
+// Regexp returns a Tokenizer that matches re repeatedly at the start of the
+// remaining input.  The kind of each token is the name of the first named
+// subexpression that matched.  Matches of no named subexpression, such as
+// white space, are skipped.  Input that re does not match is an error.
+func Regexp(re *regexp.Regexp) Tokenizer {
+	anchored := regexp.MustCompile(`\A(?:` + re.String() + `)`)
+	names := anchored.SubexpNames()
+	return func(input string) ([]Token, error) {
+		var toks []Token
+		for off := 0; off < len(input); {
+			m := anchored.FindStringSubmatchIndex(input[off:])
+			if m == nil || m[1] == 0 {
+				return toks, fmt.Errorf("offset %d: no token matches %q", off, excerpt(input, off))
+			}
+			for i := 1; i < len(names); i++ {
+				if names[i] != "" && m[2*i] >= 0 {
+					toks = append(toks, Token{Kind: names[i], Text: input[off+m[0] : off+m[1]], Offset: off})
+					break
+				}
+			}
+			off += m[1]
+		}
+		return toks, nil
+	}
+}
+
+// A Divergence is the first difference between the tokens of two
+// tokenizers.  Got or Want is nil if one tokenizer produced fewer tokens.
+type Divergence struct {
+	Input string
+	Index int    // index of the first differing token
+	Got   *Token // token from the tokenizer under test
+	Want  *Token // token from the reference tokenizer
+	Err   error  // error from either tokenizer in place of a token, if any
+}
+
+// Error describes d with the line of input where the tokenizers diverge.
+func (d *Divergence) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "token %d: ", d.Index)
+	switch {
+	case d.Got == nil:
+		fmt.Fprintf(&b, "missing %v", d.Want)
+	case d.Want == nil:
+		fmt.Fprintf(&b, "unexpected %v", d.Got)
+	default:
+		fmt.Fprintf(&b, "got %v, want %v", d.Got, d.Want)
+	}
+	if d.Err != nil {
+		fmt.Fprintf(&b, " (%v)", d.Err)
+	}
+	off := len(d.Input)
+	for _, tok := range []*Token{d.Got, d.Want} {
+		if tok != nil && tok.Offset < off {
+			off = tok.Offset
+		}
+	}
+	b.WriteByte('\n')
+	b.WriteString(context(d.Input, off))
+	return b.String()
+}
+
+// Diff runs got and want over input and returns the first divergence between
+// their tokens, or nil if they agree.  Tokens agree if they have equal kinds,
+// text and offsets.
+func Diff(got, want Tokenizer, input string) *Divergence {
+	gtoks, gerr := got(input)
+	wtoks, werr := want(input)
+	for i := 0; ; i++ {
+		var g, w *Token
+		if i < len(gtoks) {
+			g = &gtoks[i]
+		}
+		if i < len(wtoks) {
+			w = &wtoks[i]
+		}
+		if g == nil && w == nil {
+			if (gerr == nil) != (werr == nil) {
+				return &Divergence{Input: input, Index: i, Err: firstErr(gerr, werr)}
+			}
+			return nil
+		}
+		if g == nil || w == nil || *g != *w {
+			d := &Divergence{Input: input, Index: i, Got: g, Want: w}
+			if g == nil {
+				d.Err = gerr
+			} else if w == nil {
+				d.Err = werr
+			}
+			return d
+		}
+	}
+}
+
+// DiffCorpus runs Diff over each input of corpus, keyed by name, and returns
+// the divergences found keyed by the same names.
+func DiffCorpus(got, want Tokenizer, corpus map[string]string) map[string]*Divergence {
+	diffs := make(map[string]*Divergence)
+	for name, input := range corpus {
+		if d := Diff(got, want, input); d != nil {
+			diffs[name] = d
+		}
+	}
+	return diffs
+}
+
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// context returns the line of input containing off followed by a line with a
+// caret beneath off.
+func context(input string, off int) string {
+	if off > len(input) {
+		off = len(input)
+	}
+	lo := strings.LastIndexByte(input[:off], '\n') + 1
+	hi := strings.IndexByte(input[off:], '\n')
+	if hi < 0 {
+		hi = len(input)
+	} else {
+		hi += off
+	}
+	col := utf8.RuneCountInString(input[lo:off])
+	return "\t" + input[lo:hi] + "\n\t" + strings.Repeat(" ", col) + "^"
+}
+
+func excerpt(input string, off int) string {
+	s := input[off:]
+	if len(s) > 10 {
+		s = s[:10]
+	}
+	return s
+}
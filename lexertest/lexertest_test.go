@@ -0,0 +1,74 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexertest
+
+import (
+	"regexp"
+	"testing"
+	"text/scanner"
+
+	lexer "github.com/bmatsuo/go-lexer"
+)
+
+const (
+	itemIdent lexer.ItemType = iota
+	itemInt
+)
+
+var typeNames = map[lexer.ItemType]string{itemIdent: "Ident", itemInt: "Int"}
+
+func lexTokens(l *lexer.Lexer) lexer.StateFn {
+	l.IgnoreRun(" \n")
+	switch {
+	case l.AcceptRun("abcdefghijklmnopqrstuvwxyz") > 0:
+		l.Emit(itemIdent)
+	case l.AcceptRun("0123456789") > 0:
+		l.Emit(itemInt)
+	default:
+		if _, n := l.Advance(); n == 0 {
+			return nil
+		}
+		return l.Errorf("unexpected input")
+	}
+	return lexTokens
+}
+
+var reference = Regexp(regexp.MustCompile(`(?P<Ident>[a-zA-Z]+)|(?P<Int>[0-9]+)|\s+`))
+
+func TestDiffAgree(t *testing.T) {
+	got := Lexer(lexTokens, nil, lexer.WithTypeNames(typeNames))
+	for _, input := range []string{"", "abc 123", "a1 b2\nc3"} {
+		if d := Diff(got, reference, input); d != nil {
+			t.Errorf("%q: %v", input, d)
+		}
+	}
+}
+
+func TestDiffDiverge(t *testing.T) {
+	got := Lexer(lexTokens, nil, lexer.WithTypeNames(typeNames))
+	d := Diff(got, reference, "abc 12\nxy Z")
+	if d == nil {
+		t.Fatal("no divergence")
+	}
+	if d.Index != 3 || d.Got != nil || d.Want == nil || d.Want.Text != "Z" || d.Err == nil {
+		t.Errorf("unexpected divergence %#v", d)
+	}
+	d = Diff(got, Scanner(scanner.ScanIdents|scanner.ScanInts), "abc x1")
+	if d == nil || d.Index != 1 {
+		t.Fatalf("unexpected divergence %v", d)
+	}
+	want := "token 1: got Ident \"x\" at offset 4, want Ident \"x1\" at offset 4\n\tabc x1\n\t    ^"
+	if msg := d.Error(); msg != want {
+		t.Errorf("message %q, want %q", msg, want)
+	}
+}
+
+func TestDiffCorpus(t *testing.T) {
+	got := Lexer(lexTokens, nil, lexer.WithTypeNames(typeNames))
+	diffs := DiffCorpus(got, reference, map[string]string{"ok": "a 1", "bad": "a Z"})
+	if len(diffs) != 1 || diffs["bad"] == nil {
+		t.Errorf("unexpected divergences %v", diffs)
+	}
+}
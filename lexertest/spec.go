@@ -0,0 +1,97 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexertest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	lexer "github.com/bmatsuo/go-lexer"
+)
+
+// ParseSpec parses a compact description of expected items, such as
+//
+//	IDENT(foo) ASSIGN NUMBER(42) STRING("a b")
+//
+// into Wants.  Each item is a type name given to WithTypeNames, or a special
+// type name such as Error, looked up in src.  A value in parentheses is
+// compared with the value of the item, and is either a Go quoted string or
+// any text without a closing parenthesis.  An item without a value matches
+// any value.
+func ParseSpec(src *lexer.Source, spec string) ([]Want, error) {
+	var want []Want
+	for s := strings.TrimSpace(spec); s != ""; s = strings.TrimSpace(s) {
+		i := strings.IndexAny(s, "( \t\n")
+		if i < 0 {
+			i = len(s)
+		}
+		name := s[:i]
+		t, ok := src.LookupType(name)
+		if !ok {
+			return nil, fmt.Errorf("spec item %d: unknown type name %q", len(want), name)
+		}
+		w := Want{Type: t, AnyValue: true}
+		s = s[i:]
+		if strings.HasPrefix(s, "(") {
+			value, rest, err := specValue(s[1:])
+			if err != nil {
+				return nil, fmt.Errorf("spec item %d: %v", len(want), err)
+			}
+			w.Value, w.AnyValue, s = value, false, rest
+		}
+		want = append(want, w)
+	}
+	return want, nil
+}
+
+// specValue parses a value following an opening parenthesis and returns the
+// value and the text after its closing parenthesis.
+func specValue(s string) (value, rest string, err error) {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "`") {
+		q, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid quoted value %s", s)
+		}
+		value, _ = strconv.Unquote(q)
+		s = s[len(q):]
+		if !strings.HasPrefix(s, ")") {
+			return "", "", fmt.Errorf("missing ) after %s", q)
+		}
+		return value, s[1:], nil
+	}
+	i := strings.IndexByte(s, ')')
+	if i < 0 {
+		return "", "", fmt.Errorf("missing ) after %q", s)
+	}
+	return s[:i], s[i+1:], nil
+}
+
+// RunSpecs runs a subtest for each input of specs, which maps inputs to the
+// items expected from them in the form parsed by ParseSpec.  Each subtest
+// lexes its input with a lexer returned by newLexer and checks the items with
+// AssertItems.  Subtests run in the order of their inputs.
+func RunSpecs(t *testing.T, newLexer func(input string) *lexer.Lexer, specs map[string]string) {
+	t.Helper()
+	inputs := make([]string, 0, len(specs))
+	for input := range specs {
+		inputs = append(inputs, input)
+	}
+	sort.Strings(inputs)
+	for _, input := range inputs {
+		input, spec := input, specs[input]
+		t.Run(strconv.Quote(input), func(t *testing.T) {
+			t.Helper()
+			l := newLexer(input)
+			want, err := ParseSpec(l.Source(), spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			AssertItems(t, l, want)
+		})
+	}
+}
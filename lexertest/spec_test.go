@@ -0,0 +1,41 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexertest
+
+import (
+	"testing"
+
+	lexer "github.com/bmatsuo/go-lexer"
+)
+
+func newTestLexer(input string) *lexer.Lexer {
+	return lexer.New(lexTokens, input, lexer.WithTypeNames(typeNames))
+}
+
+func TestRunSpecs(t *testing.T) {
+	RunSpecs(t, newTestLexer, map[string]string{
+		"":           "",
+		"abc 123":    "Ident(abc) Int(123)",
+		"a1 b2\nc3":  "Ident Int(1) Ident(b) Int Ident(c) Int(3)",
+		"x ?":        `Ident(x) Error("unexpected input")`,
+		"xy  42 z  ": "Ident(xy)\n\tInt(42)\n\tIdent(z)",
+	})
+}
+
+func TestParseSpec(t *testing.T) {
+	src := newTestLexer("").Source()
+	want, err := ParseSpec(src, "Int Ident(a b) Error(`x)`)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(want) != 3 || !want[0].AnyValue || want[1].Value != "a b" || want[2].Type != lexer.ItemError || want[2].Value != "x)" {
+		t.Errorf("unexpected wants %+v", want)
+	}
+	for _, spec := range []string{"Float(1)", "Int(1", `Int("1"`, `Int("1)`} {
+		if _, err := ParseSpec(src, spec); err == nil {
+			t.Errorf("%q: no error", spec)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"unicode"
+)
+
+// A Matcher advances a lexer over input it recognizes and returns true if it
+// matched.  A Matcher that returns false may leave the lexer's position
+// anywhere; AcceptSeq restores the position of failed matches.  Matchers must
+// not emit items.
+type Matcher func(*Lexer) bool
+
+// AcceptSeq advances the lexer over input matching each of ms in order.  If any
+// matcher fails the lexer's position is restored to what it was before the
+// call.  AcceptSeq returns true if l advanced.
+func (l *Lexer) AcceptSeq(ms ...Matcher) bool {
+	mark := l.mark()
+	for _, m := range ms {
+		if !m(l) {
+			l.reset(mark)
+			return false
+		}
+	}
+	return true
+}
+
+// MatchString returns a Matcher for the literal string s.
+func MatchString(s string) Matcher {
+	return func(l *Lexer) bool { return l.AcceptString(s) }
+}
+
+// MatchAny returns a Matcher for a single rune in valid.
+func MatchAny(valid string) Matcher {
+	return func(l *Lexer) bool { return l.Accept(valid) }
+}
+
+// MatchRun returns a Matcher for one or more runes in valid.
+func MatchRun(valid string) Matcher {
+	return func(l *Lexer) bool { return l.AcceptRun(valid) > 0 }
+}
+
+// MatchFunc returns a Matcher for a single rune for which fn returns true.
+func MatchFunc(fn func(rune) bool) Matcher {
+	return func(l *Lexer) bool { return l.AcceptFunc(fn) }
+}
+
+// MatchRange returns a Matcher for a single rune in tab.
+func MatchRange(tab *unicode.RangeTable) Matcher {
+	return func(l *Lexer) bool { return l.AcceptRange(tab) }
+}
+
+// Repeat returns a Matcher that matches m exactly n times in succession.
+func Repeat(m Matcher, n int) Matcher {
+	return func(l *Lexer) bool {
+		for i := 0; i < n; i++ {
+			if !m(l) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Seq returns a Matcher for each of ms in succession.  If any matcher fails
+// the lexer's position is restored.
+func Seq(ms ...Matcher) Matcher {
+	return func(l *Lexer) bool { return l.AcceptSeq(ms...) }
+}
+
+// Alt returns a Matcher for the first of ms that matches, an ordered choice.
+// The lexer's position is restored after each failed alternative, so
+// alternatives may share a prefix.
+func Alt(ms ...Matcher) Matcher {
+	return func(l *Lexer) bool {
+		mark := l.mark()
+		for _, m := range ms {
+			if m(l) {
+				return true
+			}
+			l.reset(mark)
+		}
+		return false
+	}
+}
+
+// Many returns a Matcher for zero or more successive matches of m.  It always
+// matches, stopping when m fails or matches without advancing.
+func Many(m Matcher) Matcher {
+	return func(l *Lexer) bool {
+		for {
+			mark := l.mark()
+			if !m(l) {
+				l.reset(mark)
+				return true
+			}
+			if l.pos == mark.pos {
+				return true
+			}
+		}
+	}
+}
+
+// Optional returns a Matcher for zero or one match of m.  It always matches.
+func Optional(m Matcher) Matcher {
+	return func(l *Lexer) bool {
+		mark := l.mark()
+		if !m(l) {
+			l.reset(mark)
+		}
+		return true
+	}
+}
+
+// Not returns a Matcher that matches, without advancing, where m does not
+// match.  It is a negative lookahead, as in Seq(MatchString("*"),
+// Not(MatchString("/"))).
+func Not(m Matcher) Matcher {
+	return func(l *Lexer) bool {
+		mark := l.mark()
+		ok := m(l)
+		l.reset(mark)
+		return !ok
+	}
+}
+
+// A Rule is an alternative of a StateFn built with Rules.
+type Rule struct {
+	Type  ItemType
+	Match Matcher
+	Skip  bool // discard the matched input instead of emitting it
+}
+
+// Rules returns a StateFn for lexers of regular structure.  At each position
+// the first of rules that matches non-empty input is applied, either emitting
+// the input as an item of the rule's type or discarding it, and the StateFn
+// continues.  If no rule matches an error is emitted.  The StateFn returns nil
+// once the input is consumed.
+//
+//	lexer.New(lexer.Rules(
+//		lexer.Rule{Match: lexer.MatchRun(" \t\n"), Skip: true},
+//		lexer.Rule{Type: ItemNumber, Match: lexer.Seq(digits, lexer.Optional(fraction))},
+//		lexer.Rule{Type: ItemIdent, Match: ident},
+//	), input)
+func Rules(rules ...Rule) StateFn {
+	var state StateFn
+	state = func(l *Lexer) StateFn {
+		if l.pos >= len(l.input) {
+			return nil
+		}
+		mark := l.mark()
+		for _, r := range rules {
+			if !r.Match(l) || l.pos == mark.pos {
+				l.reset(mark)
+				continue
+			}
+			if r.Skip {
+				l.Ignore()
+			} else {
+				l.Emit(r.Type)
+			}
+			return state
+		}
+		c, _ := l.Advance()
+		return l.Errorf("unexpected %q", c)
+	}
+	return state
+}
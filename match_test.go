@@ -0,0 +1,149 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+func TestAcceptSeq(t *testing.T) {
+	hex := MatchAny("0123456789abcdefABCDEF")
+	escape := []Matcher{MatchString(`\u`), Repeat(hex, 4)}
+	for _, test := range []struct {
+		input string
+		ok    bool
+	}{
+		{"\\u00e9", true},
+		{"\\u00e", false},
+		{"\\x00e9", false},
+		{"\\u00eF9", true},
+	} {
+		l := New(func(*Lexer) StateFn { return nil }, test.input)
+		l.Advance()
+		l.Backup()
+		last, width := l.Last()
+		ok := l.AcceptSeq(escape...)
+		if ok != test.ok {
+			t.Errorf("%q: got %v want %v", test.input, ok, test.ok)
+		}
+		if ok && l.Current() != test.input[:6] {
+			t.Errorf("%q: matched %q", test.input, l.Current())
+		}
+		if !ok {
+			if l.Pos() != 0 {
+				t.Errorf("%q: position not restored (%d)", test.input, l.Pos())
+			}
+			if r, n := l.Last(); r != last || n != width {
+				t.Errorf("%q: last rune not restored", test.input)
+			}
+		}
+	}
+}
+
+func TestAcceptRegexp(t *testing.T) {
+	semver := regexp.MustCompile(`v?\d+\.\d+\.\d+`)
+	l := New(func(*Lexer) StateFn { return nil }, "x v1.22.3-rc1")
+	if l.AcceptRegexp(semver) {
+		t.Errorf("matched unanchored input")
+	}
+	l.AcceptString("x ")
+	l.Ignore()
+	if !l.AcceptRegexp(semver) || l.Current() != "v1.22.3" {
+		t.Errorf("got %q", l.Current())
+	}
+	if r, _ := l.Last(); r != '3' {
+		t.Errorf("last rune %q", r)
+	}
+	if l.AcceptRegexp(regexp.MustCompile(`\d*`)) {
+		t.Errorf("accepted empty match")
+	}
+
+	// A leading ^ anchors only the first alternative.
+	l = New(func(*Lexer) StateFn { return nil }, "xxbar")
+	if l.AcceptRegexp(regexp.MustCompile(`^foo|bar`)) {
+		t.Errorf("matched %q", l.Current())
+	}
+	for i := 0; i < 2*maxAnchored; i++ {
+		l.AcceptRegexp(regexp.MustCompile("x"))
+	}
+	anchored.mu.Lock()
+	n := 0
+	anchored.m.Range(func(_, _ interface{}) bool { n++; return true })
+	if n > maxAnchored || n != anchored.n {
+		t.Errorf("%d anchored expressions cached, counted %d", n, anchored.n)
+	}
+	anchored.mu.Unlock()
+
+	// The cache is shared, so a lexer that is reset finds the copy.
+	l.Reset("x")
+	if a := anchor(semver); a != anchor(semver) {
+		t.Errorf("anchored copy not cached")
+	}
+
+	// Leftmost-longest matching is kept.
+	l = New(func(*Lexer) StateFn { return nil }, "abc")
+	longest := regexp.MustCompile(`a|ab|abc`)
+	longest.Longest()
+	if !l.AcceptRegexp(longest) || l.Current() != "abc" {
+		t.Errorf("longest match %q", l.Current())
+	}
+	l = New(func(*Lexer) StateFn { return nil }, "abc")
+	if !l.AcceptRegexp(regexp.MustCompile(`a|ab|abc`)) || l.Current() != "a" {
+		t.Errorf("leftmost-first match %q", l.Current())
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	digits := MatchRun("0123456789")
+	number := Seq(Optional(MatchAny("+-")), digits, Optional(Seq(MatchString("."), digits)))
+	comment := Seq(MatchString("/*"), Many(Alt(MatchRun("abc "), Seq(MatchString("*"), Not(MatchString("/"))))), MatchString("*/"))
+	for _, test := range []struct {
+		m     Matcher
+		input string
+		match string
+		ok    bool
+	}{
+		{number, "12", "12", true},
+		{number, "-12.5x", "-12.5", true},
+		{number, "12.x", "12", true},
+		{number, "+x", "", false},
+		{comment, "/* a*b */c", "/* a*b */", true},
+		{comment, "/* a*b *", "", false},
+		{Alt(MatchString("ab"), MatchString("abc"), MatchString("a")), "abc", "ab", true},
+		{Alt(MatchString("abd"), MatchString("a")), "abc", "a", true},
+		{Many(Optional(MatchString("a"))), "aab", "aa", true},
+		{Not(MatchString("a")), "b", "", true},
+		{Not(MatchString("a")), "a", "", false},
+	} {
+		l := New(func(*Lexer) StateFn { return nil }, test.input)
+		ok := test.m(l)
+		if ok != test.ok || ok && l.Current() != test.match {
+			t.Errorf("%q: matched %q (%v), want %q (%v)", test.input, l.Current(), ok, test.match, test.ok)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	const (
+		itemNumber ItemType = iota + 1
+		itemIdent
+	)
+	state := Rules(
+		Rule{Match: MatchRun(" \t"), Skip: true},
+		Rule{Type: itemNumber, Match: MatchRun("0123456789")},
+		Rule{Type: itemIdent, Match: Seq(MatchRun("abcxyz"), Many(MatchRun("0123456789")))},
+	)
+	l := New(state, "x1 22 abc\t?")
+	var got []string
+	for item := l.Next(); item.Type != ItemEOF; item = l.Next() {
+		got = append(got, fmt.Sprintf("%d:%s", item.Type, item))
+	}
+	want := fmt.Sprint([]string{"2:x1", "1:22", "2:abc", fmt.Sprintf("%d:unexpected '?'", ItemError)})
+	if fmt.Sprint(got) != want {
+		t.Errorf("items %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+// A memo caches the outcomes of rules run with Memoize.
+type memo struct {
+	entries map[memoKey]*memoEntry
+	hits    int
+	misses  int
+}
+
+type memoKey struct {
+	name  string
+	src   *Source
+	start int
+	pos   int
+}
+
+// A memoEntry is the outcome of a rule.  A successful rule advanced to mark,
+// left the lexeme starting at start, and emitted items.
+type memoEntry struct {
+	ok    bool
+	mark  scanMark
+	start int
+	items []Item
+}
+
+// Memoize runs rule at l's current position and returns its result.  If rule
+// returns false l is restored to its state before the call, as with Save and
+// Restore.  A rule may advance l and emit items, and is typically one
+// alternative of a speculative scan that is attempted repeatedly at the same
+// offsets.
+//
+// If l was created with WithMemo the outcome of rule is cached by name and
+// position, so that later calls with the same name at the same position
+// replay the items emitted and the distance advanced without running rule
+// again, giving packrat-style performance to lexers with ambiguous prefixes.
+// A rule must therefore depend only on the input and its position.  Outcomes
+// are not cached for rules that record errors with WithErrorCollection or
+// change the current input, nor for lexers using WithSemicolons or
+// WithLayout.
+func (l *Lexer) Memoize(name string, rule func(*Lexer) bool) bool {
+	if l.memo == nil || l.semi != nil || l.layout != nil {
+		return l.speculate(rule)
+	}
+	key := memoKey{name, l.src, l.start, l.pos}
+	if e, ok := l.memo.entries[key]; ok {
+		l.memo.hits++
+		if e.ok {
+			for i := range e.items {
+				item := e.items[i] // a copy, so consumers cannot modify the entry
+				l.push(&item)
+			}
+			l.reset(e.mark)
+			l.start = e.start
+		}
+		return e.ok
+	}
+	l.memo.misses++
+	n, nerrs := len(l.items), len(l.errs)
+	ok := l.speculate(rule)
+	if len(l.errs) != nerrs || l.src != key.src {
+		return ok
+	}
+	e := &memoEntry{ok: ok}
+	if ok {
+		e.mark, e.start = l.mark(), l.start
+		for _, item := range l.items[n:] {
+			e.items = append(e.items, *item)
+		}
+	}
+	l.memo.entries[key] = e
+	return ok
+}
+
+func (l *Lexer) speculate(rule func(*Lexer) bool) bool {
+	s := l.Save()
+	if rule(l) {
+		return true
+	}
+	l.Restore(s)
+	return false
+}
+
+// MemoStats returns the number of calls to Memoize answered from the cache
+// of a lexer created with WithMemo and the number of calls that ran their
+// rule.
+func (l *Lexer) MemoStats() (hits, misses int) {
+	if l.memo == nil {
+		return 0, 0
+	}
+	return l.memo.hits, l.memo.misses
+}
@@ -0,0 +1,72 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+func TestLexerMemoize(t *testing.T) {
+	const (
+		itemName ItemType = iota + 1
+		itemCall
+		itemIndex
+	)
+	var runs int
+	name := func(l *Lexer) bool {
+		runs++
+		if l.AcceptRun("abcdefghijklmnopqrstuvwxyz") == 0 {
+			return false
+		}
+		l.Emit(itemName)
+		return true
+	}
+	// Each alternative lexes the name again through Memoize.
+	alt := func(open string, t ItemType) func(*Lexer) bool {
+		return func(l *Lexer) bool {
+			if !l.Memoize("name", name) || !l.AcceptString(open) {
+				return false
+			}
+			l.Emit(t)
+			return true
+		}
+	}
+	var state StateFn
+	state = func(l *Lexer) StateFn {
+		l.IgnoreRun(" ")
+		switch {
+		case l.Memoize("call", alt("(", itemCall)):
+		case l.Memoize("index", alt("[", itemIndex)):
+		case l.Memoize("name", name):
+		default:
+			return nil
+		}
+		return state
+	}
+	for _, opts := range [][]Option{nil, {WithMemo()}} {
+		runs = 0
+		l := New(state, "f( a[ b", opts...)
+		var got []ItemType
+		for item := l.Next(); item.Type != ItemEOF; item = l.Next() {
+			got = append(got, item.Type)
+		}
+		want := []ItemType{itemName, itemCall, itemName, itemIndex, itemName}
+		if len(got) != len(want) {
+			t.Fatalf("items %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("items %v, want %v", got, want)
+			}
+		}
+		hits, misses := l.MemoStats()
+		switch {
+		case opts == nil && runs != 9:
+			t.Errorf("name ran %d times without a memo", runs)
+		case opts != nil && (runs != 4 || hits != 5 || misses != 11):
+			t.Errorf("name ran %d times with %d hits and %d misses", runs, hits, misses)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics accumulates counters over every lexer created with WithMetrics, for
+// monitoring the throughput and error rate of a lexing service.  A lexer adds
+// its counts when Next or NextBudget first returns ItemEOF, when Step first
+// returns false, or when it is Reset before then, unless it never ran a state
+// function.  Metrics is safe for concurrent use.
+//
+// Metrics implements expvar.Var, so it can be published with
+//
+//	expvar.Publish("lexer", m)
+//
+// and Collect adapts it to other monitoring systems.
+type Metrics struct {
+	mu   sync.Mutex
+	snap MetricsSnapshot
+}
+
+// A MetricsSnapshot is a copy of the counters of Metrics.
+type MetricsSnapshot struct {
+	Documents int64            `json:"documents"` // inputs lexed
+	Bytes     int64            `json:"bytes"`     // total length of the inputs
+	Errors    int64            `json:"errors"`    // errors, including those collected with WithErrorCollection
+	Items     map[string]int64 `json:"items"`     // items emitted by type name
+	Duration  time.Duration    `json:"duration"`  // time from the first state function of each input until it was done
+}
+
+// Snapshot returns a copy of the counters of m.
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.snap
+	s.Items = make(map[string]int64, len(m.snap.Items))
+	for name, n := range m.snap.Items {
+		s.Items[name] = n
+	}
+	return s
+}
+
+// String returns the counters of m as a JSON object.
+func (m *Metrics) String() string {
+	b, _ := json.Marshal(m.Snapshot())
+	return string(b)
+}
+
+// Collect calls fn with the name and value of each counter of m, in the
+// style of a Prometheus collector.  The counts of items are reported with a
+// "type" label holding the type name, and the duration in seconds.
+func (m *Metrics) Collect(fn func(name string, value float64, labels map[string]string)) {
+	s := m.Snapshot()
+	fn("lexer_documents_total", float64(s.Documents), nil)
+	fn("lexer_bytes_total", float64(s.Bytes), nil)
+	fn("lexer_errors_total", float64(s.Errors), nil)
+	fn("lexer_duration_seconds_total", s.Duration.Seconds(), nil)
+	names := make([]string, 0, len(s.Items))
+	for name := range s.Items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fn("lexer_items_total", float64(s.Items[name]), map[string]string{"type": name})
+	}
+}
+
+// lexStats are the counts of a single lexer not yet added to its Metrics.
+type lexStats struct {
+	m      *Metrics
+	begin  time.Time // when the first state function ran
+	bytes  int
+	errors int64
+	items  map[ItemType]int64
+	done   bool
+}
+
+// flush adds the counts of l to its Metrics once, if l began lexing.
+func (s *lexStats) flush(l *Lexer) {
+	if s.done || s.begin.IsZero() {
+		return
+	}
+	s.done = true
+	d := time.Since(s.begin)
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	snap := &s.m.snap
+	snap.Documents++
+	snap.Bytes += int64(s.bytes)
+	snap.Errors += s.errors
+	snap.Duration += d
+	if snap.Items == nil {
+		snap.Items = make(map[string]int64)
+	}
+	for t, n := range s.items {
+		snap.Items[(&Item{Type: t, src: l.src}).TypeName()] += n
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	m := new(Metrics)
+	names := WithTypeNames(map[ItemType]string{1: "word"})
+	pool := &Pool{New: func(input string) *Lexer {
+		return New(lexRecorded, input, names, WithMetrics(m), WithErrorCollection())
+	}}
+	for _, input := range []string{"ab cd", "x ? y"} {
+		l := pool.Get(input)
+		Collect(l)
+		l.Next() // a second EOF is not counted
+		pool.Put(l)
+	}
+	l := pool.Get("abandoned")
+	l.Next()
+	l.Reset("")
+	s := m.Snapshot()
+	if s.Documents != 3 || s.Bytes != 19 || s.Errors != 1 {
+		t.Errorf("unexpected counts %+v", s)
+	}
+	if s.Items["word"] != 4 || len(s.Items) != 1 {
+		t.Errorf("unexpected item counts %v", s.Items)
+	}
+	var decoded MetricsSnapshot
+	if err := json.Unmarshal([]byte(m.String()), &decoded); err != nil || decoded.Items["word"] != 4 {
+		t.Errorf("unexpected JSON %s: %v", m.String(), err)
+	}
+	var collected []string
+	m.Collect(func(name string, value float64, labels map[string]string) {
+		if labels != nil {
+			name += "{type=" + labels["type"] + "}"
+		}
+		collected = append(collected, name)
+	})
+	if len(collected) != 5 || collected[4] != "lexer_items_total{type=word}" {
+		t.Errorf("collected %q", collected)
+	}
+}
+
+func TestMetricsNextBudget(t *testing.T) {
+	m := new(Metrics)
+	l := New(lexRecorded, "ab cd", WithMetrics(m))
+	for i, _ := l.NextBudget(Budget{States: 1}); i == nil || i.Type != ItemEOF; i, _ = l.NextBudget(Budget{States: 1}) {
+	}
+	l = New(lexRecorded, "ab", WithMetrics(m))
+	for _, ok := l.Step(); ok; _, ok = l.Step() {
+	}
+	if s := m.Snapshot(); s.Documents != 2 || s.Bytes != 7 {
+		t.Errorf("unexpected counts %+v", s)
+	}
+}
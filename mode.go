@@ -0,0 +1,71 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+// Modes maps the names of a lexer's modes to their start states.  A mode is a
+// sub-language with its own set of state functions, such as the tags, text
+// and scripts of an HTML document.
+type Modes map[string]StateFn
+
+// NewModal returns a lexer for input that starts in the mode named initial.
+// NewModal panics if initial is not one of modes.
+func NewModal(modes Modes, initial string, input string, opts ...Option) *Lexer {
+	start, ok := modes[initial]
+	if !ok {
+		panic("unknown initial mode " + initial)
+	}
+	enter := func(l *Lexer) {
+		l.modeStack = append(l.modeStack, initial)
+		l.modeBase = 1
+	}
+	return New(start, input, append([]Option{WithModes(modes), enter}, opts...)...)
+}
+
+// Mode returns the name of l's current mode, or an empty string if l has not
+// entered a mode.
+func (l *Lexer) Mode() string {
+	if len(l.modeStack) == 0 {
+		return ""
+	}
+	return l.modeStack[len(l.modeStack)-1]
+}
+
+// ModeDepth returns the number of modes l has entered and not exited.
+func (l *Lexer) ModeDepth() int {
+	return len(l.modeStack)
+}
+
+// EnterMode switches l to the mode called name and returns its start state.
+// The current mode is resumed by a matching call to ExitMode.  An error is
+// emitted if name is not a registered mode.
+//
+//	if l.AcceptString(`"`) {
+//		return l.EnterMode("string")
+//	}
+func (l *Lexer) EnterMode(name string) StateFn {
+	start, ok := l.modes[name]
+	if !ok {
+		return l.Errorf("unknown lexer mode %q", name)
+	}
+	l.modeStack = append(l.modeStack, name)
+	return start
+}
+
+// ExitMode leaves l's current mode and returns the start state of the mode
+// that was current when it was entered, or the start state given to New when
+// l leaves the last mode entered with EnterMode.  A state function that must resume
+// elsewhere in the enclosing mode can save its state with PushState before
+// calling EnterMode and return PopState after calling ExitMode.  An error is
+// emitted if no mode was entered with EnterMode.
+func (l *Lexer) ExitMode() StateFn {
+	if len(l.modeStack) <= l.modeBase {
+		return l.Errorf("no lexer mode to exit")
+	}
+	l.modeStack = l.modeStack[:len(l.modeStack)-1]
+	if len(l.modeStack) == 0 {
+		return l.init
+	}
+	return l.modes[l.Mode()]
+}
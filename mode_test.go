@@ -0,0 +1,109 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+const (
+	modeText ItemType = iota
+	modeTag
+	modeQuote
+)
+
+var testModes = Modes{
+	"text": func(l *Lexer) StateFn {
+		l.AcceptRunNotAny("<")
+		if l.Pos() > l.Start() {
+			l.Emit(modeText)
+		}
+		if l.AcceptString("<") {
+			l.Ignore()
+			return l.EnterMode("tag")
+		}
+		return nil
+	},
+	"tag": func(l *Lexer) StateFn {
+		l.AcceptRunNotAny(">\"")
+		if l.Pos() > l.Start() {
+			l.Emit(modeTag)
+		}
+		switch {
+		case l.AcceptString("\""):
+			l.Ignore()
+			return l.EnterMode("quote")
+		case l.AcceptString(">"):
+			l.Ignore()
+			return l.ExitMode()
+		}
+		return l.Errorf("unclosed tag in mode %s", l.Mode())
+	},
+	"quote": func(l *Lexer) StateFn {
+		l.AcceptRunNotAny("\"")
+		l.Emit(modeQuote)
+		if !l.AcceptString("\"") {
+			return l.Errorf("unterminated quote")
+		}
+		l.Ignore()
+		return l.ExitMode()
+	},
+}
+
+func TestModes(t *testing.T) {
+	items := Collect(NewModal(testModes, "text", `a<b "c">d<e`))
+	want := []struct {
+		typ   ItemType
+		value string
+	}{
+		{modeText, "a"},
+		{modeTag, "b "},
+		{modeQuote, "c"},
+		{modeText, "d"},
+		{modeTag, "e"},
+		{ItemError, "unclosed tag in mode tag"},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d: %v", len(items), len(want), items)
+	}
+	for i, w := range want {
+		if items[i].Type != w.typ || items[i].Value != w.value {
+			t.Errorf("item %d: %v %q, want %v %q", i, items[i].Type, items[i].Value, w.typ, w.value)
+		}
+	}
+}
+
+func TestModeErrors(t *testing.T) {
+	l := NewModal(testModes, "text", "")
+	if l.Mode() != "text" || l.ModeDepth() != 1 {
+		t.Fatalf("mode %q depth %d", l.Mode(), l.ModeDepth())
+	}
+	l.EnterMode("missing")
+	if i := l.Next(); i.Type != ItemError || i.Value != `unknown lexer mode "missing"` {
+		t.Errorf("unexpected item %v %q", i.Type, i.Value)
+	}
+	l.ExitMode()
+	if i := l.Next(); i.Type != ItemError || i.Value != "no lexer mode to exit" {
+		t.Errorf("unexpected item %v %q", i.Type, i.Value)
+	}
+}
+
+func TestWithModes(t *testing.T) {
+	l := New(testModes["text"], "a<b>c", WithModes(testModes))
+	var values []string
+	for item := l.Next(); item.Type != ItemEOF; item = l.Next() {
+		values = append(values, item.Value)
+	}
+	if len(values) != 3 || values[0] != "a" || values[1] != "b" || values[2] != "c" {
+		t.Errorf("unexpected values %q", values)
+	}
+	if l.Mode() != "" || l.ModeDepth() != 0 {
+		t.Errorf("mode %q depth %d", l.Mode(), l.ModeDepth())
+	}
+	l.ExitMode()
+	if i := l.Next(); i.Type != ItemError || i.Value != "no lexer mode to exit" {
+		t.Errorf("unexpected item %v %q", i.Type, i.Value)
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"strings"
+)
+
+// emitNewlines emits the line breaks in the current lexeme as items of type
+// l.newline.
+func (l *Lexer) emitNewlines() {
+	text := l.input[l.start:l.pos]
+	for off := 0; ; {
+		i := strings.IndexAny(text[off:], "\r\n")
+		if i < 0 {
+			return
+		}
+		off += i
+		n := 1
+		if strings.HasPrefix(text[off:], "\r\n") {
+			n = 2
+		}
+		pos := l.start + off
+		off += n
+		l.enqueue(&Item{Type: l.newline, Pos: pos, Value: text[off-n : off], end: pos + n})
+	}
+}
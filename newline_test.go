@@ -0,0 +1,53 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+const semiNewline ItemType = 10
+
+func TestNewlines(t *testing.T) {
+	items := Collect(New(lexSemiTokens, "a\r\nb \n\rc", WithNewlines(semiNewline)))
+	want := []struct {
+		typ   ItemType
+		value string
+		pos   int
+	}{
+		{semiIdent, "a", 0},
+		{semiNewline, "\r\n", 1},
+		{semiIdent, "b", 3},
+		{semiNewline, "\n", 5},
+		{semiNewline, "\r", 6},
+		{semiIdent, "c", 7},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d: %v", len(items), len(want), items)
+	}
+	for i, w := range want {
+		it := items[i]
+		if it.Type != w.typ || it.Value != w.value || it.Pos != w.pos {
+			t.Errorf("item %d: %v %q at %d, want %v %q at %d", i, it.Type, it.Value, it.Pos, w.typ, w.value, w.pos)
+		}
+	}
+}
+
+func TestNewlinesSemicolons(t *testing.T) {
+	rule := SemicolonRule{Semicolon: semiSemicolon, After: []ItemType{semiIdent}}
+	items := Collect(New(lexSemiTokens, "a\n\nb", WithNewlines(semiNewline), WithSemicolons(rule)))
+	want := []ItemType{semiIdent, semiSemicolon, semiNewline, semiNewline, semiIdent}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d: %v", len(items), len(want), items)
+	}
+	for i, typ := range want {
+		if items[i].Type != typ {
+			t.Errorf("item %d: type %v, want %v", i, items[i].Type, typ)
+		}
+	}
+	if items[1].Pos != 1 {
+		t.Errorf("semicolon at %d", items[1].Pos)
+	}
+}
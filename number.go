@@ -0,0 +1,152 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// NumberKind is the kind of a numeric literal scanned by ScanGoNumber.
+type NumberKind uint8
+
+// Kinds of numeric literals.
+const (
+	NumberNone  NumberKind = iota // no literal was scanned
+	NumberInt                     // integer literal
+	NumberFloat                   // floating-point literal
+	NumberImag                    // imaginary literal
+)
+
+func (k NumberKind) String() string {
+	switch k {
+	case NumberNone:
+		return "none"
+	case NumberInt:
+		return "int"
+	case NumberFloat:
+		return "float"
+	case NumberImag:
+		return "imaginary"
+	}
+	return fmt.Sprintf("NumberKind(%d)", uint8(k))
+}
+
+// ScanGoNumber advances l over a numeric literal in the syntax of the Go
+// specification, including 0b, 0o, and 0x prefixes, legacy octal literals,
+// '_' digit separators, hexadecimal floats, and the imaginary suffix 'i'.
+// ScanGoNumber returns NumberNone without advancing if the input does not
+// begin with a digit or a '.' followed by a digit.
+//
+// Malformed literals are scanned as far as go/scanner would scan them and the
+// first problem found is returned as an *Error positioned at the offending
+// byte.  The error is not emitted.
+func (l *Lexer) ScanGoNumber() (NumberKind, error) {
+	s := numberScanner{l: l, offs: l.pos}
+	return s.scan()
+}
+
+// ScanFloat advances l over a decimal number with an optional fraction and
+// exponent.  Forms with a leading dot (.5), a trailing dot (5.), and a signed
+// exponent (5e-3) are accepted.  ScanFloat returns NumberInt if the number has
+// neither a fraction nor an exponent and NumberFloat otherwise.  If the input
+// does not begin with a digit or a '.' followed by a digit ScanFloat returns
+// NumberNone without advancing.
+//
+// An exponent without digits, as in "1e" or "1e+", is scanned and reported as
+// an *Error positioned where the digits were expected.
+func (l *Lexer) ScanFloat() (NumberKind, error) {
+	switch c, _ := l.Peek(); {
+	case isDecimal(c):
+	case c == '.' && len(l.input) > l.pos+1 && isDecimal(rune(l.input[l.pos+1])):
+	default:
+		return NumberNone, nil
+	}
+	const digits = "0123456789"
+	kind := NumberInt
+	l.AcceptRun(digits)
+	if l.Accept(".") {
+		kind = NumberFloat
+		l.AcceptRun(digits)
+	}
+	if l.Accept("eE") {
+		kind = NumberFloat
+		l.Accept("+-")
+		if l.AcceptRun(digits) == 0 {
+			return kind, l.errorAt(l.pos, "exponent has no digits")
+		}
+	}
+	return kind, nil
+}
+
+// A Number is the value of a numeric literal, attached to its item by
+// EmitNumber so that a parser need not convert the text again.
+type Number struct {
+	Kind  NumberKind
+	Base  int     // 2, 8, 10 or 16, from the prefix of the literal
+	Int   int64   // value of an integer literal
+	Float float64 // value of the literal, or the imaginary part of an imaginary literal
+	Err   error   // *strconv.NumError if the value is out of range or malformed
+}
+
+// EmitNumber emits the current lexeme, a numeric literal of the given kind
+// as scanned by ScanGoNumber or ScanFloat, as an Item of type t with its
+// parsed Number as payload.  Retrieve the value with PayloadOf[Number].
+//
+// The Float of an integer literal holds its value as converted to float64.
+// An integer literal too large for int64 has the Err and clamped Int returned
+// by strconv.ParseInt, and the nearest Float.
+func (l *Lexer) EmitNumber(t ItemType, kind NumberKind) {
+	l.EmitWith(t, ParseNumber(l.Current(), kind))
+}
+
+// ParseNumber parses text, a numeric literal of the given kind in the syntax
+// accepted by ScanGoNumber.
+func ParseNumber(text string, kind NumberKind) Number {
+	n := Number{Kind: kind, Base: numberBase(text, kind)}
+	switch kind {
+	case NumberInt:
+		n.Int, n.Err = strconv.ParseInt(text, 0, 64)
+		if n.Err == nil {
+			n.Float = float64(n.Int)
+		} else if b, ok := new(big.Int).SetString(text, 0); ok {
+			n.Float, _ = new(big.Float).SetInt(b).Float64()
+		}
+	case NumberFloat:
+		n.Float, n.Err = strconv.ParseFloat(text, 64)
+	case NumberImag:
+		text = strings.TrimSuffix(text, "i")
+		n.Float, n.Err = strconv.ParseFloat(text, 64)
+		if n.Err != nil && n.Base != 10 {
+			// Integer mantissas with a prefix, such as 0x10i.
+			if i, err := strconv.ParseInt(text, 0, 64); err == nil {
+				n.Float, n.Err = float64(i), nil
+			}
+		}
+	}
+	return n
+}
+
+// numberBase returns the base of a numeric literal from its prefix.  As in
+// Go, the mantissa of a legacy octal float or imaginary literal is decimal.
+func numberBase(text string, kind NumberKind) int {
+	if len(text) < 2 || text[0] != '0' {
+		return 10
+	}
+	switch lower(rune(text[1])) {
+	case 'x':
+		return 16
+	case 'o':
+		return 8
+	case 'b':
+		return 2
+	}
+	if kind == NumberInt {
+		return 8
+	}
+	return 10
+}
@@ -0,0 +1,128 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScanGoNumber(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		lit   string
+		kind  NumberKind
+		err   string
+		pos   int
+	}{
+		{"x", "", NumberNone, "", 0},
+		{".x", "", NumberNone, "", 0},
+		{"42;", "42", NumberInt, "", 0},
+		{"0b1010_1010", "0b1010_1010", NumberInt, "", 0},
+		{"0o17", "0o17", NumberInt, "", 0},
+		{"0x_1F", "0x_1F", NumberInt, "", 0},
+		{"017", "017", NumberInt, "", 0},
+		{"1_000.5e-3", "1_000.5e-3", NumberFloat, "", 0},
+		{".5", ".5", NumberFloat, "", 0},
+		{"5.", "5.", NumberFloat, "", 0},
+		{"0x1.8p1", "0x1.8p1", NumberFloat, "", 0},
+		{"1e3i", "1e3i", NumberImag, "", 0},
+		{"0b102", "0b102", NumberInt, "invalid digit '2' in binary literal", 4},
+		{"089", "089", NumberInt, "invalid digit '8' in octal literal", 1},
+		{"1__0", "1__0", NumberInt, "'_' must separate successive digits", 2},
+		{"1_", "1_", NumberInt, "'_' must separate successive digits", 1},
+		{"1e+", "1e+", NumberFloat, "exponent has no digits", 3},
+		{"0x", "0x", NumberInt, "hexadecimal literal has no digits", 2},
+		{"0x1.8", "0x1.8", NumberFloat, "hexadecimal mantissa requires a 'p' exponent", 5},
+		{"0b1.0", "0b1.0", NumberFloat, "invalid radix point in binary literal", 3},
+		{"1p2", "1p2", NumberFloat, "'p' exponent requires hexadecimal mantissa", 1},
+	} {
+		l := New(func(*Lexer) StateFn { return nil }, test.input)
+		kind, err := l.ScanGoNumber()
+		if kind != test.kind || l.Current() != test.lit {
+			t.Errorf("%q: got %v %q want %v %q", test.input, kind, l.Current(), test.kind, test.lit)
+		}
+		switch {
+		case err == nil && test.err != "":
+			t.Errorf("%q: expected error %q", test.input, test.err)
+		case err != nil && test.err == "":
+			t.Errorf("%q: unexpected error %v", test.input, err)
+		case err != nil:
+			if e := err.(*Error); e.Value != test.err || e.Pos != test.pos {
+				t.Errorf("%q: got error %q at %d want %q at %d", test.input, e.Value, e.Pos, test.err, test.pos)
+			}
+		}
+	}
+}
+
+func TestScanFloat(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		lit   string
+		kind  NumberKind
+		err   bool
+	}{
+		{"", "", NumberNone, false},
+		{".", "", NumberNone, false},
+		{"e5", "", NumberNone, false},
+		{"12", "12", NumberInt, false},
+		{".5", ".5", NumberFloat, false},
+		{"5.", "5.", NumberFloat, false},
+		{"5.e+1", "5.e+1", NumberFloat, false},
+		{"1.25E-3x", "1.25E-3", NumberFloat, false},
+		{"1e", "1e", NumberFloat, true},
+		{"1e-x", "1e-", NumberFloat, true},
+	} {
+		l := New(func(*Lexer) StateFn { return nil }, test.input)
+		kind, err := l.ScanFloat()
+		if kind != test.kind || l.Current() != test.lit || (err != nil) != test.err {
+			t.Errorf("%q: got %v %q %v", test.input, kind, l.Current(), err)
+		}
+		if err != nil && err.(*Error).Pos != len(test.lit) {
+			t.Errorf("%q: error at %d", test.input, err.(*Error).Pos)
+		}
+	}
+}
+
+func TestEmitNumber(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		kind  NumberKind
+		base  int
+		i     int64
+		f     float64
+		err   bool
+	}{
+		{"42", NumberInt, 10, 42, 42, false},
+		{"0x_1F", NumberInt, 16, 31, 31, false},
+		{"0b101", NumberInt, 2, 5, 5, false},
+		{"017", NumberInt, 8, 15, 15, false},
+		{"0o17", NumberInt, 8, 15, 15, false},
+		{"1_000.5e-3", NumberFloat, 10, 0, 1.0005, false},
+		{"0x1.8p1", NumberFloat, 16, 0, 3, false},
+		{"017.5", NumberFloat, 10, 0, 17.5, false},
+		{"1e3i", NumberImag, 10, 0, 1000, false},
+		{"0x10i", NumberImag, 16, 0, 16, false},
+		{"18446744073709551616", NumberInt, 10, math.MaxInt64, 18446744073709551616, true},
+	} {
+		l := New(func(l *Lexer) StateFn {
+			kind, err := l.ScanGoNumber()
+			if err != nil {
+				return l.Errorf("%v", err)
+			}
+			l.EmitNumber(1, kind)
+			return nil
+		}, test.input)
+		item := l.Next()
+		n, ok := PayloadOf[Number](item)
+		if !ok {
+			t.Errorf("%q: no number in %v", test.input, item)
+			continue
+		}
+		if n.Kind != test.kind || n.Base != test.base || n.Int != test.i || n.Float != test.f || (n.Err != nil) != test.err {
+			t.Errorf("%q: got %+v", test.input, n)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+	"sync"
+)
+
+// An Option configures a Lexer.  Options are passed to New and applied in the
+// order given, before any input is scanned.
+type Option func(*Lexer)
+
+// WithFilename attaches name to the lexer's Source.  The name is included in
+// item positions and in the messages of errors emitted by the lexer.
+func WithFilename(name string) Option {
+	return func(l *Lexer) {
+		l.src.name = name
+	}
+}
+
+// WithData attaches v to the lexer as though by calling SetData.
+func WithData(v interface{}) Option {
+	return func(l *Lexer) {
+		l.data = v
+	}
+}
+
+// WithSync makes the parser API of the lexer safe for concurrent use by
+// multiple goroutines.  Calls are serialized internally and state functions
+// execute in the goroutine of the caller holding the lock.
+//
+// Each item is returned by exactly one call to Next, and items are returned in
+// the order they were emitted, with respect to the order in which calls were
+// serialized.  After the lexer has stopped, all concurrent and subsequent calls
+// return ItemEOF.
+func WithSync() Option {
+	return func(l *Lexer) {
+		l.mu = new(sync.Mutex)
+	}
+}
+
+// WithErrorCollection causes the lexer to record errors instead of emitting
+// them as items, so that error items do not terminate a parser's iteration.
+// After ItemEOF is returned by Next, Errors returns every recorded error.
+// Warnings are still emitted as items.
+func WithErrorCollection() Option {
+	return func(l *Lexer) {
+		l.collect = true
+	}
+}
+
+// WithErrorLimit limits the number of errors recorded by a lexer created with
+// WithErrorCollection.  When the nth error is recorded the lexer emits a final
+// "too many errors" ItemError and stops.
+func WithErrorLimit(n int) Option {
+	return func(l *Lexer) {
+		l.limit = n
+	}
+}
+
+// WithErrorHandler calls h with the position and message of each error
+// instead of emitting an ItemError, in the manner of go/scanner.ErrorHandler,
+// so that a parser can receive errors out of band and read only significant
+// items from Next.  Warnings are still emitted as items.  Combined with
+// WithErrorCollection the error is also recorded, and h is not called for
+// errors after the limit given to WithErrorLimit is reached.
+func WithErrorHandler(h func(pos Position, msg string)) Option {
+	return func(l *Lexer) {
+		l.onError = h
+	}
+}
+
+// WithWhitespace sets the runes skipped by SkipSpace and reported by IsSpace,
+// for languages whose whitespace differs from unicode.IsSpace, for example by
+// excluding line breaks that are significant or including only ASCII.
+func WithWhitespace(s *RuneSet) Option {
+	return func(l *Lexer) {
+		l.classes.Space = s
+	}
+}
+
+// WithClasses sets the character classes used by SkipSpace, IsSpace, IsDigit
+// and IsLetter.  A nil field of c leaves the class unchanged.  ASCIIClasses
+// trades the Unicode-correct default definitions for byte-table tests, for
+// lexers of machine-generated input where speed matters more than generality.
+func WithClasses(c CharClasses) Option {
+	return func(l *Lexer) {
+		if c.Space != nil {
+			l.classes.Space = c.Space
+		}
+		if c.Digit != nil {
+			l.classes.Digit = c.Digit
+		}
+		if c.Letter != nil {
+			l.classes.Letter = c.Letter
+		}
+	}
+}
+
+// WithRegion restricts lexing to input[lo:hi].  The lexer starts at lo and
+// reaches the end of its input at hi, while positions of items and errors
+// remain offsets in the whole input, so a region of a larger document can be
+// lexed without translating positions.  The text before lo is not scanned
+// but remains visible through Input.  WithRegion panics unless
+// 0 <= lo <= hi <= len(input).
+func WithRegion(lo, hi int) Option {
+	return func(l *Lexer) {
+		if lo < 0 || lo > hi || hi > len(l.input) {
+			panic(fmt.Sprintf("lexer: region [%d, %d] outside of input [0, %d]", lo, hi, len(l.input)))
+		}
+		l.input = l.input[:hi]
+		l.start, l.pos = lo, lo
+	}
+}
+
+// WithHistory sets the number of emitted items retained for LastEmitted.  The
+// default is 1; zero disables the history.  WithHistory panics if n < 0.
+func WithHistory(n int) Option {
+	return func(l *Lexer) {
+		if n < 0 {
+			panic(fmt.Sprintf("lexer: negative history length %d", n))
+		}
+		l.hist = make([]*Item, n)
+		l.nhist = 0
+	}
+}
+
+// WithMaxTokenLength limits the lexemes emitted by l to n bytes, so that
+// malicious input cannot produce unbounded tokens.  A longer lexeme is
+// discarded and reported as an error wrapping ErrTokenTooLong at its
+// position, after which lexing continues.  Markers and injected items are not
+// limited.
+func WithMaxTokenLength(n int) Option {
+	return func(l *Lexer) {
+		l.maxToken = n
+	}
+}
+
+// WithItemsAll retains a copy of every item delivered by Next for ItemsAll,
+// for analyses such as highlighting and folding that need the whole stream
+// after lexing.
+func WithItemsAll() Option {
+	return func(l *Lexer) {
+		l.retain = true
+	}
+}
+
+// WithMemo enables caching of the rules run with Memoize.
+func WithMemo() Option {
+	return func(l *Lexer) {
+		l.memo = &memo{entries: make(map[memoKey]*memoEntry)}
+	}
+}
+
+// WithExpected records the input attempted and not accepted at each
+// position, for ExpectedSoFar and Unexpected.
+func WithExpected() Option {
+	return func(l *Lexer) {
+		l.expect = &expectation{pos: -1}
+	}
+}
+
+// WithInterner canonicalizes the values of emitted items through in.
+func WithInterner(in *Interner) Option {
+	return func(l *Lexer) {
+		l.intern = in
+	}
+}
+
+// WithTypeNames registers names for the item types of the lexer's language.
+// Names are used by Item.TypeName and by the item formatters in this package,
+// and names of special types may be overridden.
+func WithTypeNames(names map[ItemType]string) Option {
+	return func(l *Lexer) {
+		l.src.names = names
+	}
+}
+
+// WithTransform rewrites the input read by Advance through fn, for example
+// SpliceLines.  The values of emitted items are the transformed text while
+// their positions remain offsets in the original input.  Item.StreamPos and
+// Source.StreamOffset map original offsets into the transformed text.
+// Methods that search the input directly, such as AcceptString, AdvanceTo and
+// AcceptRegexp, see the original input.
+func WithTransform(fn Transform) Option {
+	return func(l *Lexer) {
+		l.src.transform = fn
+	}
+}
+
+// WithModes registers the modes that state functions may switch between with
+// EnterMode and ExitMode.
+func WithModes(modes Modes) Option {
+	return func(l *Lexer) {
+		l.modes = modes
+	}
+}
+
+// WithSemicolons inserts semicolons into the lexer's items according to rule.
+// The newline must appear in input that was not emitted as part of an item,
+// or be a line break emitted by WithNewlines, so a lexer that emits comments
+// containing newlines as items should insert semicolons itself.  Inserted
+// items report true from Item.Synthetic.
+func WithSemicolons(rule SemicolonRule) Option {
+	return func(l *Lexer) {
+		s := &semicolons{rule: rule, after: make(map[ItemType]bool)}
+		for _, t := range rule.After {
+			s.after[t] = true
+		}
+		l.semi = s
+	}
+}
+
+// WithNewlines emits an item of type t for each line break in text thrown
+// away with Ignore or one of the Ignore and Skip methods, so that state
+// functions of line-oriented languages need not handle line breaks
+// themselves.  "\n", "\r\n" and "\r" are recognized as line breaks and the
+// value of each item is the line break as it appears in the input.  Line
+// breaks inside emitted lexemes are not affected.
+func WithNewlines(t ItemType) Option {
+	return func(l *Lexer) {
+		l.newlines, l.newline = true, t
+	}
+}
+
+// WithLayout inserts the items of the off-side rule described by layout.
+func WithLayout(layout *Layout) Option {
+	return func(l *Lexer) {
+		l.layout = newLayout(layout)
+	}
+}
+
+// WithRecorder logs the operations of the lexer to rec.
+func WithRecorder(rec *Recorder) Option {
+	return func(l *Lexer) {
+		l.rec = rec
+	}
+}
+
+// WithMetrics adds the counts of the lexer to m.  The counts of a lexer reset
+// with Reset, as by a Pool, are added for each input that it began to lex.
+func WithMetrics(m *Metrics) Option {
+	return func(l *Lexer) {
+		l.stats = &lexStats{m: m, bytes: len(l.input), items: make(map[ItemType]int64)}
+	}
+}
+
+// WithTracer notifies t of the lifecycle of the lexer and of each state
+// function it runs.
+func WithTracer(t Tracer) Option {
+	return func(l *Lexer) {
+		l.trace = &tracing{t: t, doc: DocumentTrace{Size: len(l.input)}}
+	}
+}
+
+// WithInvalidUTF8 sets the policy for input that is not valid UTF-8.  The
+// default policy, InvalidReplace, reads an invalid byte as utf8.RuneError so
+// that lexing continues.
+func WithInvalidUTF8(p InvalidPolicy) Option {
+	return func(l *Lexer) {
+		l.invalid = p
+	}
+}
+
+// WithDecoder decodes the input read by Advance with d instead of as UTF-8,
+// for example UTF16LE.  The values of emitted items are the bytes of the
+// input in its encoding.  The policy set by WithInvalidUTF8 does not apply,
+// and methods that search the input directly for bytes or strings, such as
+// AcceptString and AcceptRunNotAny, assume an encoding compatible with ASCII.
+func WithDecoder(d Decoder) Option {
+	return func(l *Lexer) {
+		l.decoder = d
+	}
+}
+
+// WithDisplayColumns makes the columns of positions count the terminal cells
+// occupied by the preceding text on the line, as given by DisplayWidth,
+// instead of runes.  Carets placed beneath such a column line up under
+// East Asian wide characters, emoji and combining marks.
+func WithDisplayColumns() Option {
+	return func(l *Lexer) {
+		l.src.cells = true
+	}
+}
+
+// WithBidiWarnings emits an ItemWarning at each Unicode bidirectional control
+// character the lexer advances over, such as U+202E RIGHT-TO-LEFT OVERRIDE,
+// because such characters can make source text display differently than it
+// is lexed.  Each character is reported once even if it is read again after
+// Backup.  See IsBidiControl.
+func WithBidiWarnings() Option {
+	return func(l *Lexer) {
+		l.bidi = true
+	}
+}
+
+// WithInvariants makes the lexer check its invariants as it runs, for use
+// while developing and debugging a lexer.  A violation panics with a message
+// naming the executing state function and the position in the input.  The
+// invariants checked are
+//
+//   - the start and current positions are within the input,
+//   - Backup is not called twice without reading a rune in between,
+//   - Emit is not called for an empty lexeme, use EmitMarker instead,
+//   - Emit is not called with ItemEOF, ItemError or ItemWarning.
+func WithInvariants() Option {
+	return func(l *Lexer) {
+		l.debug = true
+	}
+}
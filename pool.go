@@ -0,0 +1,42 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import "sync"
+
+// A Pool caches lexers for reuse by programs that lex many inputs, such as
+// a server lexing the body of each request.  A lexer taken from the Pool is
+// reset to its start state and options with Reset, so no item, error, mode or
+// saved state of a previous input survives.  A Pool is safe for concurrent
+// use and must not be copied after first use.
+//
+//	var pool = lexer.Pool{New: func(input string) *lexer.Lexer {
+//		return lexer.New(lexStart, input, lexer.WithErrorCollection())
+//	}}
+type Pool struct {
+	// New returns a new lexer for input when the Pool is empty.
+	New func(input string) *Lexer
+
+	pool sync.Pool
+}
+
+// Get returns a lexer for input, reusing one returned to p with Put if
+// possible.
+func (p *Pool) Get(input string) *Lexer {
+	if l, ok := p.pool.Get().(*Lexer); ok {
+		l.Reset(input)
+		return l
+	}
+	return p.New(input)
+}
+
+// Put returns l to p.  The items emitted by l and the lexer itself must not
+// be used after they are returned.  Put releases l's references to its input
+// and items immediately, so that a pooled lexer does not keep them alive.
+// Its options are not applied again until it is taken from p with Get.
+func (p *Pool) Put(l *Lexer) {
+	l.release()
+	p.pool.Put(l)
+}
@@ -0,0 +1,74 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	pool := &Pool{New: func(input string) *Lexer {
+		return NewModal(testModes, "text", input, WithErrorCollection())
+	}}
+	l := pool.Get(`a<b "c`)
+	Collect(l)
+	if len(l.Errors()) != 1 || l.ModeDepth() != 3 {
+		t.Fatalf("errors %v depth %d", l.Errors(), l.ModeDepth())
+	}
+	pool.Put(l)
+	if l.Input() != "" || len(l.items) != 0 {
+		t.Errorf("pooled lexer retains input %q or %d items", l.Input(), len(l.items))
+	}
+
+	l = pool.Get("d<e>f")
+	if l.Mode() != "text" || l.ModeDepth() != 1 || len(l.Errors()) != 0 {
+		t.Fatalf("reused lexer in mode %q depth %d with errors %v", l.Mode(), l.ModeDepth(), l.Errors())
+	}
+	var values []string
+	for _, item := range Collect(l) {
+		values = append(values, item.Value)
+	}
+	if len(values) != 3 || values[0] != "d" || values[1] != "e" || values[2] != "f" {
+		t.Errorf("unexpected values %q", values)
+	}
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors %v", l.Errors())
+	}
+}
+
+func TestPoolRegion(t *testing.T) {
+	pool := &Pool{New: func(input string) *Lexer {
+		return New(lexRunes, input, WithRegion(0, 3))
+	}}
+	l := pool.Get("abcdef")
+	Collect(l)
+	pool.Put(l)
+	l = pool.Get("xyzw")
+	if items := Collect(l); len(items) != 3 || items[2].Value != "z" {
+		t.Errorf("unexpected items %v", items)
+	}
+}
+
+func TestPoolConcurrent(t *testing.T) {
+	pool := &Pool{New: func(input string) *Lexer {
+		return NewModal(testModes, "text", input)
+	}}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l := pool.Get(`x<y "z">w`)
+				if n := len(Collect(l)); n != 4 {
+					t.Errorf("got %d items", n)
+				}
+				pool.Put(l)
+			}
+		}()
+	}
+	wg.Wait()
+}
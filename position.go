@@ -0,0 +1,169 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// A Source is an input string with an optional filename.  A Source resolves
+// byte offsets into line and column numbers.  Line information is computed
+// the first time it is needed.
+type Source struct {
+	name  string
+	text  string
+	names map[ItemType]string // item type names given to WithTypeNames
+
+	cells bool // columns count display cells, see WithDisplayColumns
+
+	parent *Source // source that included this one with PushInput
+	at     int     // offset in parent of the inclusion
+
+	once  sync.Once
+	lines []int // offset of the first byte of each line
+
+	transform Transform // transform given to WithTransform, if any
+	remapOnce sync.Once
+	remaps    []remap // offsets rewritten by transform
+}
+
+// Name returns the filename of s, which may be empty.
+func (s *Source) Name() string {
+	return s.name
+}
+
+// Text returns the complete input of s.
+func (s *Source) Text() string {
+	return s.text
+}
+
+// TypeName returns the name of t given to WithTypeNames, or t.String() if t
+// has no name.
+func (s *Source) TypeName(t ItemType) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	return t.String()
+}
+
+// LookupType returns the item type named name by WithTypeNames, or the special
+// type with that name such as "EOF" or "Error".
+func (s *Source) LookupType(name string) (ItemType, bool) {
+	for t, n := range s.names {
+		if n == name {
+			return t, true
+		}
+	}
+	for _, t := range []ItemType{ItemEOF, ItemError, ItemWarning} {
+		if t.String() == name {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// Parent returns the Source that was being lexed when s was pushed with
+// PushInput, and the offset in the parent at which s was included.  Parent
+// returns nil if s was not pushed.
+func (s *Source) Parent() (*Source, int) {
+	return s.parent, s.at
+}
+
+// derive returns a new Source for text with the same configuration as s.
+func (s *Source) derive(name, text string) *Source {
+	return &Source{name: name, text: text, names: s.names, cells: s.cells, transform: s.transform}
+}
+
+// Position resolves offset into a Position.  Offsets outside of s are clamped
+// to the nearest valid offset.
+func (s *Source) Position(offset int) Position {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(s.text) {
+		offset = len(s.text)
+	}
+	s.once.Do(s.index)
+	i := sort.SearchInts(s.lines, offset+1) - 1
+	var col int
+	if s.cells {
+		col = DisplayWidth(s.text[s.lines[i]:offset]) + 1
+	} else {
+		col = utf8.RuneCountInString(s.text[s.lines[i]:offset]) + 1
+	}
+	return Position{
+		Filename: s.name,
+		Offset:   offset,
+		Line:     i + 1,
+		Column:   col,
+	}
+}
+
+// line returns the offsets of the first byte of line n, starting at 1, and
+// of the line break or end of input that ends it.
+func (s *Source) line(n int) (start, end int) {
+	s.once.Do(s.index)
+	start = s.lines[n-1]
+	end = len(s.text)
+	if n < len(s.lines) {
+		end = s.lines[n] - 1
+	}
+	if end > start && s.text[end-1] == '\r' {
+		end--
+	}
+	return start, end
+}
+
+func (s *Source) index() {
+	s.lines = append(s.lines, 0)
+	for off := 0; ; {
+		i := strings.IndexByte(s.text[off:], '\n')
+		if i < 0 {
+			return
+		}
+		off += i + 1
+		s.lines = append(s.lines, off)
+	}
+}
+
+// Position describes a location in a Source.  A Position is valid if Line is
+// greater than zero.
+type Position struct {
+	Filename string // filename, if any
+	Offset   int    // byte offset, starting at 0
+	Line     int    // line number, starting at 1
+	Column   int    // column number in runes or display cells, starting at 1
+}
+
+// IsValid returns true if p has a line number.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String returns p in one of the forms
+//
+//	file:line:col
+//	line:col
+//	file
+//	-
+//
+// the last form is used for invalid positions without a filename.
+func (p Position) String() string {
+	s := p.Filename
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
+}
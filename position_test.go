@@ -0,0 +1,49 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+func TestSourcePosition(t *testing.T) {
+	src := &Source{name: "a.txt", text: "ab\nαβγ\n\nd"}
+	for _, test := range []struct {
+		offset int
+		want   string
+	}{
+		{0, "a.txt:1:1"},
+		{2, "a.txt:1:3"},
+		{3, "a.txt:2:1"},
+		{5, "a.txt:2:2"},
+		{10, "a.txt:3:1"},
+		{11, "a.txt:4:1"},
+		{100, "a.txt:4:2"},
+	} {
+		if got := src.Position(test.offset).String(); got != test.want {
+			t.Errorf("offset %d: got %q want %q", test.offset, got, test.want)
+		}
+	}
+}
+
+func TestWithFilename(t *testing.T) {
+	start := func(l *Lexer) StateFn {
+		l.AcceptRun("a\n")
+		return l.Errorf("unexpected %q", l.Input()[l.Pos():])
+	}
+	item := New(start, "a\naab", WithFilename("x.txt")).Next()
+	err := item.Err()
+	if err == nil {
+		t.Fatalf("expected an error item: %v", item)
+	}
+	if want := `x.txt:1:1: unexpected "b" (offset 0, near "a")`; err.Error() != want {
+		t.Errorf("got %q want %q", err.Error(), want)
+	}
+
+	item = New(start, "a\naab").Next()
+	if want := `1:1: unexpected "b" (offset 0, near "a")`; item.Err().Error() != want {
+		t.Errorf("got %q want %q", item.Err().Error(), want)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package quick checks the invariants of lexers on random inputs.  Inputs are
+// composed mostly of fragments of the language being lexed, so that they
+// reach the states that hand-written tests miss.
+//
+//	err := quick.Check(lexStart, &quick.Config{
+//		Alphabet: []string{"let", "=", " ", "\n", "0", "x", "\""},
+//	})
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+package quick
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	lexer "github.com/bmatsuo/go-lexer"
+)
+
+// Config controls the inputs generated by Check.  The zero value of each
+// field selects a default, so inputs without noise require a negative Noise.
+type Config struct {
+	Alphabet []string                // fragments that inputs are composed of; default printable ASCII
+	Noise    float64                 // probability of a random rune instead of a fragment; default 0.1, none if negative
+	MaxLen   int                     // maximum number of fragments in an input; default 64
+	Count    int                     // number of inputs to check; default 100
+	MaxSteps int                     // maximum state transitions per input byte; default 16
+	Rand     *rand.Rand              // source of randomness; default seeded with 1
+	Options  []lexer.Option          // options given to lexer.New
+	Check    func(*lexer.Item) error // additional invariant checked for each item, if non-nil
+}
+
+// An Error describes an input for which an invariant did not hold.
+type Error struct {
+	Input string
+	Err   error
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("input %q: %v", err.Input, err.Err)
+}
+
+// Unwrap returns the violated invariant.
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// Check lexes random inputs starting in start and returns an *Error for the
+// first input that violates one of the following invariants.
+//
+//   - The lexer terminates within cfg.MaxSteps state transitions per byte of
+//     input.
+//   - Items scanned from the input do not overlap and are emitted in order of
+//     position.
+//   - Unless the lexer reports an error, the emitted and ignored lexemes
+//     concatenate to the input.
+//
+// A nil cfg selects the defaults with an alphabet of printable ASCII.
+func Check(start lexer.StateFn, cfg *Config) error {
+	if cfg == nil {
+		cfg = new(Config)
+	}
+	c := cfg.defaults()
+	for k := 0; k < c.Count; k++ {
+		input := c.generate()
+		if err := c.check(start, input); err != nil {
+			return &Error{Input: input, Err: err}
+		}
+	}
+	return nil
+}
+
+// Generate returns a random input composed as configured by cfg.  Check
+// uses Generate to produce its inputs.
+func Generate(cfg *Config) string {
+	c := cfg.defaults()
+	return c.generate()
+}
+
+// defaults returns a copy of c with the defaults of its zero fields.
+func (c *Config) defaults() Config {
+	d := *c
+	if d.Alphabet == nil {
+		for b := byte(' '); b <= '~'; b++ {
+			d.Alphabet = append(d.Alphabet, string(b))
+		}
+	}
+	if d.Noise == 0 {
+		d.Noise = 0.1
+	}
+	if d.MaxLen == 0 {
+		d.MaxLen = 64
+	}
+	if d.Count == 0 {
+		d.Count = 100
+	}
+	if d.MaxSteps == 0 {
+		d.MaxSteps = 16
+	}
+	if d.Rand == nil {
+		d.Rand = rand.New(rand.NewSource(1))
+	}
+	return d
+}
+
+func (c *Config) generate() string {
+	var b strings.Builder
+	for n := c.Rand.Intn(c.MaxLen + 1); n > 0; n-- {
+		if len(c.Alphabet) > 0 && c.Rand.Float64() >= c.Noise {
+			b.WriteString(c.Alphabet[c.Rand.Intn(len(c.Alphabet))])
+			continue
+		}
+		switch c.Rand.Intn(4) {
+		case 0:
+			b.WriteByte(byte(0x80 + c.Rand.Intn(0x80))) // invalid UTF-8
+		case 1:
+			b.WriteRune(rune(0x80 + c.Rand.Intn(0x10000-0x80)))
+		default:
+			b.WriteByte(byte(c.Rand.Intn(0x80)))
+		}
+	}
+	return b.String()
+}
+
+// errStuck is panicked by a state function that exceeds its budget.
+type errStuck struct{ steps int }
+
+func (c *Config) check(start lexer.StateFn, input string) (err error) {
+	budget := c.MaxSteps * (len(input) + 1)
+	steps := 0
+	var wrap func(fn lexer.StateFn) lexer.StateFn
+	wrap = func(fn lexer.StateFn) lexer.StateFn {
+		if fn == nil {
+			return nil
+		}
+		return func(l *lexer.Lexer) lexer.StateFn {
+			steps++
+			if steps > budget {
+				panic(errStuck{steps})
+			}
+			return wrap(fn(l))
+		}
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			stuck, ok := e.(errStuck)
+			if !ok {
+				panic(e)
+			}
+			err = fmt.Errorf("lexer did not terminate after %d state transitions", stuck.steps)
+		}
+	}()
+
+	rec := new(lexer.Recorder)
+	opts := append(append([]lexer.Option(nil), c.Options...), lexer.WithRecorder(rec))
+	l := lexer.New(wrap(start), input, opts...)
+	failed := false
+	end := 0
+	for n := 0; ; n++ {
+		i := l.Next()
+		if i.Type == lexer.ItemEOF {
+			break
+		}
+		if c.Check != nil {
+			if err := c.Check(i); err != nil {
+				return fmt.Errorf("item %d: %v", n, err)
+			}
+		}
+		if i.Type == lexer.ItemError {
+			failed = true
+			continue
+		}
+		if i.Type == lexer.ItemWarning || i.Synthetic() || i.Source().Text() != input {
+			continue
+		}
+		if i.Pos < end {
+			return fmt.Errorf("item %d at offset %d overlaps the preceding item ending at %d", n, i.Pos, end)
+		}
+		end = i.End()
+	}
+	if failed {
+		return nil
+	}
+	if got := reconstruct(input, rec.Ops()); got != input {
+		return fmt.Errorf("lexemes reconstruct %q", got)
+	}
+	return nil
+}
+
+// reconstruct concatenates the lexemes emitted and ignored in ops, omitting
+// those discarded by a Restore.
+func reconstruct(input string, ops []lexer.Op) string {
+	type span struct{ lo, hi int }
+	var spans []span
+	for _, op := range ops {
+		switch op.Kind {
+		case lexer.OpEmit, lexer.OpIgnore:
+			if op.Pos > op.Start {
+				spans = append(spans, span{op.Start, op.Pos})
+			}
+		case lexer.OpRestore:
+			for len(spans) > 0 && spans[len(spans)-1].hi > op.Start {
+				spans = spans[:len(spans)-1]
+			}
+		}
+	}
+	var b strings.Builder
+	for _, s := range spans {
+		if s.hi > len(input) {
+			break
+		}
+		b.WriteString(input[s.lo:s.hi])
+	}
+	return b.String()
+}
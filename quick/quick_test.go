@@ -0,0 +1,68 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quick
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	lexer "github.com/bmatsuo/go-lexer"
+)
+
+func lexWords(l *lexer.Lexer) lexer.StateFn {
+	l.IgnoreRun(" ")
+	if l.AcceptRun("abc") > 0 {
+		l.Emit(1)
+		return lexWords
+	}
+	if _, n := l.Advance(); n == 0 {
+		return nil
+	}
+	l.Emit(2)
+	return lexWords
+}
+
+func TestCheck(t *testing.T) {
+	if err := Check(lexWords, &Config{Alphabet: []string{"a", "b", "c", " ", "é", "\x00"}}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckLoop(t *testing.T) {
+	var loop lexer.StateFn
+	loop = func(l *lexer.Lexer) lexer.StateFn {
+		if strings.HasPrefix(l.Input()[l.Pos():], "x") {
+			return loop
+		}
+		return lexWords(l)
+	}
+	err := Check(loop, &Config{Alphabet: []string{"a", "x"}, Noise: -1})
+	var qerr *Error
+	if !errors.As(err, &qerr) || !strings.Contains(qerr.Input, "x") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestCheckLostInput(t *testing.T) {
+	lossy := func(l *lexer.Lexer) lexer.StateFn {
+		l.AcceptRun("ab")
+		l.Emit(1)
+		return nil
+	}
+	if err := Check(lossy, &Config{Alphabet: []string{"a", "b", "c"}}); err == nil {
+		t.Errorf("lexer that drops input passed")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	s := Generate(&Config{Alphabet: []string{"ab"}, Noise: -1, MaxLen: 10})
+	if strings.Trim(s, "ab") != "" || len(s)%2 != 0 {
+		t.Errorf("generated %q", s)
+	}
+	if s := Generate(&Config{Alphabet: []string{"a"}, MaxLen: 200}); strings.Trim(s, "a") == "" {
+		t.Errorf("generated %q without the default noise", s)
+	}
+}
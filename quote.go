@@ -0,0 +1,283 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A QuoteSpec describes the syntax of a quoted string literal for ScanQuoted.
+type QuoteSpec struct {
+	Open  string // opening delimiter
+	Close string // closing delimiter, Open if empty
+
+	// Escape introduces an escape sequence, or is 0 if the literal has no
+	// escapes.
+	Escape rune
+
+	// Escapes maps each rune that may follow Escape to the rune it denotes.
+	// If Escapes is nil any rune may follow Escape.
+	Escapes map[rune]rune
+
+	// Numeric enables the Go numeric escapes \xHH, \ooo, \uHHHH, and
+	// \UHHHHHHHH, where the escape character is Escape.
+	Numeric bool
+
+	// Doubling allows a doubled Close delimiter to denote a literal Close,
+	// as in SQL's 'it''s'.
+	Doubling bool
+
+	// Multiline allows unescaped newlines in the literal.
+	Multiline bool
+}
+
+var goEscapes = map[rune]rune{
+	'a': '\a', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v',
+	'\\': '\\', '"': '"',
+}
+
+var goRuneEscapes = map[rune]rune{
+	'a': '\a', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v',
+	'\\': '\\', '\'': '\'',
+}
+
+// Common string literal syntaxes.
+var (
+	// GoString is an interpreted Go string literal, "...".
+	GoString = QuoteSpec{Open: `"`, Escape: '\\', Escapes: goEscapes, Numeric: true}
+	// GoRune is a Go rune literal, '...'.  ScanQuoted does not check that
+	// the literal contains exactly one rune.
+	GoRune = QuoteSpec{Open: `'`, Escape: '\\', Escapes: goRuneEscapes, Numeric: true}
+	// GoRawString is a raw Go string literal, `...`.
+	GoRawString = QuoteSpec{Open: "`", Multiline: true}
+	// SQLString is a SQL string literal, '...', where '' denotes a quote.
+	SQLString = QuoteSpec{Open: `'`, Doubling: true, Multiline: true}
+)
+
+// ScanQuoted advances l over a quoted literal described by spec.  If the input
+// does not begin with spec.Open, ScanQuoted returns false without advancing.
+// Otherwise the literal is scanned through its closing delimiter and
+// ScanQuoted returns true.
+//
+// An invalid escape sequence is scanned and reported as an *Error at the
+// position of its escape character.  An unterminated literal is reported as an
+// *Error at the position of the opening delimiter, in which case l is advanced
+// to the end of the input or, for literals that are not Multiline, to the
+// offending newline.  Only the first error is returned and errors are not
+// emitted.
+func (l *Lexer) ScanQuoted(spec QuoteSpec) (bool, error) {
+	open := l.pos
+	if !l.AcceptString(spec.Open) {
+		return false, nil
+	}
+	closer := spec.Close
+	if closer == "" {
+		closer = spec.Open
+	}
+	var err *Error
+	for {
+		if l.AcceptString(closer) {
+			if spec.Doubling && l.AcceptString(closer) {
+				continue
+			}
+			break
+		}
+		c, n := l.Peek()
+		if n == 0 || c == '\n' && !spec.Multiline {
+			return true, l.wrapAt(open, "unterminated quoted literal", ErrUnexpectedEOF)
+		}
+		if spec.Escape != 0 && c == spec.Escape {
+			if e := l.scanEscape(spec); e != nil && err == nil {
+				err = e
+			}
+			continue
+		}
+		if IsInvalid(c, n) {
+			l.AdvanceByte()
+		} else {
+			l.Advance()
+		}
+	}
+	if err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// scanEscape advances l over an escape sequence of spec.
+func (l *Lexer) scanEscape(spec QuoteSpec) *Error {
+	esc := l.pos
+	l.Advance()
+	c, n := l.Peek()
+	if n == 0 {
+		return nil // reported as unterminated
+	}
+	if spec.Numeric {
+		const hex = "0123456789abcdefABCDEF"
+		const oct = "01234567"
+		ok, numeric := true, true
+		code := -1 // offset of the digits of a Unicode escape
+		switch c {
+		case 'x':
+			l.Advance()
+			ok = l.AcceptCount(hex, 2)
+		case 'u':
+			l.Advance()
+			code = l.pos
+			ok = l.AcceptCount(hex, 4)
+		case 'U':
+			l.Advance()
+			code = l.pos
+			ok = l.AcceptCount(hex, 8)
+		case '0', '1', '2', '3':
+			ok = l.AcceptCount(oct, 3)
+		default:
+			numeric = false
+		}
+		if numeric {
+			if !ok {
+				return l.errorAt(esc, "invalid numeric escape sequence")
+			}
+			if code >= 0 {
+				v, _ := strconv.ParseUint(l.input[code:l.pos], 16, 32)
+				if !utf8.ValidRune(rune(v)) {
+					return l.errorAt(esc, "escape sequence is invalid Unicode code point")
+				}
+			}
+			return nil
+		}
+	}
+	if spec.Escapes != nil {
+		if _, ok := spec.Escapes[c]; !ok {
+			if c != '\n' || spec.Multiline {
+				l.Advance()
+			}
+			return l.errorAt(esc, "unknown escape sequence")
+		}
+	}
+	if c != '\n' || spec.Multiline {
+		l.Advance()
+	}
+	return nil
+}
+
+// EmitUnquoted emits the current lexeme, a quoted literal scanned by
+// ScanQuoted with spec, as an Item of type t whose payload is the literal's
+// value with delimiters removed and escapes replaced.  The Value and position
+// of the item remain those of the raw literal.  Retrieve the value with
+// PayloadOf[string].  If the literal is malformed the item is emitted without
+// a payload and the error from Unquote is returned as an *Error at the
+// position of the malformed escape sequence.  The error is not emitted.
+func (l *Lexer) EmitUnquoted(t ItemType, spec QuoteSpec) error {
+	s, off, err := unquote(l.Current(), spec)
+	if err != nil {
+		pos := l.src.OriginalOffset(l.src.StreamOffset(l.start) + off)
+		l.Emit(t)
+		return l.errorAt(pos, err.Error())
+	}
+	l.EmitWith(t, s)
+	return nil
+}
+
+// Unquote returns the value of lit, a complete quoted literal described by
+// spec.  Numeric escapes \xHH and \ooo denote bytes and \uHHHH and \UHHHHHHHH
+// denote runes, as in Go.  If spec.Escapes is nil an escaped rune denotes
+// itself.
+func Unquote(lit string, spec QuoteSpec) (string, error) {
+	s, _, err := unquote(lit, spec)
+	return s, err
+}
+
+// unquote is Unquote but also returns the offset in lit of the error.
+func unquote(lit string, spec QuoteSpec) (string, int, error) {
+	closer := spec.Close
+	if closer == "" {
+		closer = spec.Open
+	}
+	if len(lit) < len(spec.Open)+len(closer) || !strings.HasPrefix(lit, spec.Open) || !strings.HasSuffix(lit, closer) {
+		return "", 0, errors.New("missing quote delimiters")
+	}
+	end := len(lit) - len(closer)
+	s := lit[len(spec.Open):end]
+	if spec.Escape == 0 && !spec.Doubling {
+		return s, 0, nil
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for len(s) > 0 {
+		if spec.Doubling && strings.HasPrefix(s, closer+closer) {
+			b.WriteString(closer)
+			s = s[2*len(closer):]
+			continue
+		}
+		c, n := utf8.DecodeRuneInString(s)
+		if spec.Escape == 0 || c != spec.Escape {
+			b.WriteString(s[:n])
+			s = s[n:]
+			continue
+		}
+		esc := end - len(s) // offset of the escape character in lit
+		s = s[n:]
+		if s == "" {
+			return "", esc, errors.New("escape sequence not terminated")
+		}
+		var err error
+		if s, err = unescape(&b, s, spec); err != nil {
+			return "", esc, err
+		}
+	}
+	return b.String(), 0, nil
+}
+
+// unescape writes the value of the escape sequence at the start of s, which
+// follows the escape character, to b and returns the rest of s.
+func unescape(b *strings.Builder, s string, spec QuoteSpec) (string, error) {
+	c, n := utf8.DecodeRuneInString(s)
+	if spec.Numeric {
+		digits, base := 0, 16
+		switch c {
+		case 'x':
+			digits = 2
+		case 'u':
+			digits = 4
+		case 'U':
+			digits = 8
+		case '0', '1', '2', '3':
+			digits, base, n = 3, 8, 0
+		}
+		if digits > 0 {
+			if len(s) < n+digits {
+				return "", errors.New("invalid numeric escape sequence")
+			}
+			v, err := strconv.ParseUint(s[n:n+digits], base, 32)
+			if err != nil {
+				return "", errors.New("invalid numeric escape sequence")
+			}
+			switch {
+			case c == 'u' || c == 'U':
+				if !utf8.ValidRune(rune(v)) {
+					return "", errors.New("escape sequence is invalid Unicode code point")
+				}
+				b.WriteRune(rune(v))
+			default:
+				b.WriteByte(byte(v))
+			}
+			return s[n+digits:], nil
+		}
+	}
+	if spec.Escapes == nil {
+		b.WriteString(s[:n])
+		return s[n:], nil
+	}
+	r, ok := spec.Escapes[c]
+	if !ok {
+		return "", errors.New("unknown escape sequence")
+	}
+	b.WriteRune(r)
+	return s[n:], nil
+}
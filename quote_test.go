@@ -0,0 +1,108 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+func TestScanQuoted(t *testing.T) {
+	for _, test := range []struct {
+		spec  QuoteSpec
+		input string
+		lit   string
+		ok    bool
+		err   string
+		pos   int
+	}{
+		{GoString, `x"`, ``, false, "", 0},
+		{GoString, `"a\"b\n"c`, `"a\"b\n"`, true, "", 0},
+		{GoString, `"\x41\101\u00e9\U0001F600"`, `"\x41\101\u00e9\U0001F600"`, true, "", 0},
+		{GoString, `"\q" x`, `"\q"`, true, "unknown escape sequence", 1},
+		{GoString, `"ab\x4" x`, `"ab\x4"`, true, "invalid numeric escape sequence", 3},
+		{GoString, `"a\uD800b" x`, `"a\uD800b"`, true, "escape sequence is invalid Unicode code point", 2},
+		{GoString, `"\U00110000"`, `"\U00110000"`, true, "escape sequence is invalid Unicode code point", 1},
+		{GoString, "\"ab\ncd\"", `"ab`, true, "unterminated quoted literal", 0},
+		{GoString, `"abc\`, `"abc\`, true, "unterminated quoted literal", 0},
+		{GoRawString, "`a\\n\nb`", "`a\\n\nb`", true, "", 0},
+		{SQLString, `'it''s' x`, `'it''s'`, true, "", 0},
+		{SQLString, `'it'`, `'it'`, true, "", 0},
+		{QuoteSpec{Open: "<<", Close: ">>", Escape: '%'}, `<<a%>>b>>`, `<<a%>>b>>`, true, "", 0},
+	} {
+		l := New(func(*Lexer) StateFn { return nil }, test.input)
+		ok, err := l.ScanQuoted(test.spec)
+		if ok != test.ok || l.Current() != test.lit {
+			t.Errorf("%q: got %v %q want %v %q", test.input, ok, l.Current(), test.ok, test.lit)
+		}
+		switch {
+		case err == nil && test.err != "":
+			t.Errorf("%q: expected error %q", test.input, test.err)
+		case err != nil && test.err == "":
+			t.Errorf("%q: unexpected error %v", test.input, err)
+		case err != nil:
+			if e := err.(*Error); e.Value != test.err || e.Pos != test.pos {
+				t.Errorf("%q: got error %q at %d want %q at %d", test.input, e.Value, e.Pos, test.err, test.pos)
+			}
+		}
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	for _, test := range []struct {
+		spec  QuoteSpec
+		lit   string
+		value string
+		err   string
+	}{
+		{GoString, `"a\"b\n"`, "a\"b\n", ""},
+		{GoString, `"\x41\101\u00e9\U0001F600"`, "AAé\U0001F600", ""},
+		{GoString, `"\xff"`, "\xff", ""},
+		{GoString, `"\q"`, "", "unknown escape sequence"},
+		{GoString, `"\ud800"`, "", "escape sequence is invalid Unicode code point"},
+		{GoString, `"\400"`, "", "unknown escape sequence"},
+		{GoString, `"ab`, "", "missing quote delimiters"},
+		{GoRune, `'\''`, "'", ""},
+		{GoRawString, "`a\\n\nb`", "a\\n\nb", ""},
+		{SQLString, `'it''s'`, "it's", ""},
+		{QuoteSpec{Open: "<<", Close: ">>", Escape: '%'}, `<<a%>>b%%>>`, "a>>b%", ""},
+	} {
+		value, err := Unquote(test.lit, test.spec)
+		if value != test.value || err == nil && test.err != "" || err != nil && err.Error() != test.err {
+			t.Errorf("%s: got %q, %v; want %q, %q", test.lit, value, err, test.value, test.err)
+		}
+	}
+}
+
+func TestEmitUnquoted(t *testing.T) {
+	var err error
+	l := New(func(l *Lexer) StateFn {
+		if ok, _ := l.ScanQuoted(GoString); !ok {
+			return nil
+		}
+		err = l.EmitUnquoted(1, GoString)
+		return nil
+	}, `"tab\there"`)
+	item := l.Next()
+	if s, ok := PayloadOf[string](item); err != nil || !ok || s != "tab\there" || item.Value != `"tab\there"` {
+		t.Errorf("unexpected item %q with payload %q: %v", item.Value, item.Payload, err)
+	}
+}
+
+func TestEmitUnquotedError(t *testing.T) {
+	var err error
+	l := New(func(l *Lexer) StateFn {
+		l.AcceptString("x = ")
+		l.Ignore()
+		l.ScanQuoted(GoString)
+		err = l.EmitUnquoted(1, GoString)
+		return nil
+	}, `x = "ab\q"`)
+	if item := l.Next(); item.Payload != nil {
+		t.Errorf("unexpected payload %v", item.Payload)
+	}
+	if e, ok := err.(*Error); !ok || e.Value != "unknown escape sequence" || e.Pos != 7 {
+		t.Errorf("unexpected error %#v", err)
+	}
+}
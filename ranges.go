@@ -0,0 +1,179 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A RangeError reports a malformed spec given to Ranges.
+type RangeError struct {
+	Spec   string // the spec given to Ranges
+	Offset int    // byte offset of the error in Spec
+	Msg    string
+}
+
+// Error returns a description of the error and its offset.
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("lexer: range %q: offset %d: %s", e.Spec, e.Offset, e.Msg)
+}
+
+// Ranges compiles spec into a RuneSet, allowing large classes to be written
+// compactly.  Each rune of spec is a member of the set, and two runes
+// separated by a hyphen, as in "a-z", denote the runes between them
+// inclusive.  A hyphen at the beginning or end of spec is a member.  A
+// backslash escapes the following rune, so \- and \\ are a hyphen and a
+// backslash, and \t, \n and \r are the usual control characters.
+//
+//	ident, err := lexer.Ranges("a-zA-Z0-9_")
+//
+// A POSIX character class name in brackets, such as [:alpha:] or [:space:],
+// is the corresponding Unicode class: alnum, alpha, blank, cntrl, digit,
+// graph, lower, print, punct, space, upper, word and xdigit.  A class cannot
+// be the end of a range, and a literal "[:" must be written "\[:".
+//
+// The returned set is used with AcceptSet and AcceptRunSet.  If spec is
+// malformed Ranges returns a *RangeError.
+func Ranges(spec string) (*RuneSet, error) {
+	return parseRanges(spec, unicodeClasses)
+}
+
+// ASCIIRanges is like Ranges but POSIX class names denote their ASCII
+// definitions, for use with lexers given ASCIIClasses.
+func ASCIIRanges(spec string) (*RuneSet, error) {
+	return parseRanges(spec, asciiClasses)
+}
+
+// unicodeClasses and asciiClasses are the POSIX classes of Ranges and
+// ASCIIRanges.
+var (
+	unicodeClasses = map[string]*RuneSet{
+		"alnum":  NewRuneSet(unicode.Letter, unicode.Digit),
+		"alpha":  NewRuneSet(unicode.Letter),
+		"blank":  NewRuneSet(unicode.Zs, "\t"),
+		"cntrl":  NewRuneSet(unicode.Cc),
+		"digit":  NewRuneSet(unicode.Digit),
+		"graph":  NewRuneSet(unicode.L, unicode.M, unicode.N, unicode.P, unicode.S),
+		"lower":  NewRuneSet(unicode.Lower),
+		"print":  NewRuneSet(unicode.L, unicode.M, unicode.N, unicode.P, unicode.S, " "),
+		"punct":  NewRuneSet(unicode.P),
+		"space":  UnicodeSpace,
+		"upper":  NewRuneSet(unicode.Upper),
+		"word":   NewRuneSet(unicode.Letter, unicode.Digit, "_"),
+		"xdigit": NewRuneSet(RuneRange('0', '9'), RuneRange('A', 'F'), RuneRange('a', 'f')),
+	}
+	asciiClasses = map[string]*RuneSet{
+		"alnum":  NewRuneSet(RuneRange('0', '9'), RuneRange('A', 'Z'), RuneRange('a', 'z')),
+		"alpha":  NewRuneSet(RuneRange('A', 'Z'), RuneRange('a', 'z')),
+		"blank":  NewRuneSet(" \t"),
+		"cntrl":  NewRuneSet(RuneRange(0, 0x1f), rune(0x7f)),
+		"digit":  RuneRange('0', '9'),
+		"graph":  RuneRange('!', '~'),
+		"lower":  RuneRange('a', 'z'),
+		"print":  RuneRange(' ', '~'),
+		"punct":  NewRuneSet(RuneRange('!', '/'), RuneRange(':', '@'), RuneRange('[', '`'), RuneRange('{', '~')),
+		"space":  ASCIIClasses.Space,
+		"upper":  RuneRange('A', 'Z'),
+		"word":   NewRuneSet(RuneRange('0', '9'), RuneRange('A', 'Z'), RuneRange('a', 'z'), "_"),
+		"xdigit": NewRuneSet(RuneRange('0', '9'), RuneRange('A', 'F'), RuneRange('a', 'f')),
+	}
+)
+
+func parseRanges(spec string, classes map[string]*RuneSet) (*RuneSet, error) {
+	p := rangeParser{spec: spec}
+	var rs []runeRange
+	for p.pos < len(spec) {
+		at := p.pos
+		if strings.HasPrefix(spec[p.pos:], "[:") {
+			end := strings.Index(spec[p.pos+2:], ":]")
+			if end < 0 {
+				return nil, p.errorf(at, "unterminated character class")
+			}
+			name := spec[p.pos+2 : p.pos+2+end]
+			class, ok := classes[name]
+			if !ok {
+				return nil, p.errorf(at, "unknown character class [:%s:]", name)
+			}
+			rs = append(rs, class.ranges...)
+			p.pos += end + 4
+			continue
+		}
+		lo, err := p.rune()
+		if err != nil {
+			return nil, err
+		}
+		hi := lo
+		if p.pos+1 < len(spec) && spec[p.pos] == '-' {
+			p.pos++
+			if strings.HasPrefix(spec[p.pos:], "[:") {
+				return nil, p.errorf(p.pos, "character class in range")
+			}
+			if hi, err = p.rune(); err != nil {
+				return nil, err
+			}
+			if hi < lo {
+				return nil, p.errorf(at, "range %c-%c out of order", lo, hi)
+			}
+		}
+		rs = append(rs, runeRange{lo, hi})
+	}
+	return newRuneSet(rs), nil
+}
+
+// MustRanges is like Ranges but panics if spec is malformed.  It simplifies
+// the initialization of package-level sets.
+func MustRanges(spec string) *RuneSet {
+	s, err := Ranges(spec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+type rangeParser struct {
+	spec string
+	pos  int
+}
+
+func (p *rangeParser) errorf(pos int, format string, vs ...interface{}) error {
+	return &RangeError{Spec: p.spec, Offset: pos, Msg: fmt.Sprintf(format, vs...)}
+}
+
+// rune returns the possibly escaped rune at p's position and advances past
+// it.
+func (p *rangeParser) rune() (rune, error) {
+	start := p.pos
+	r, n := utf8.DecodeRuneInString(p.spec[p.pos:])
+	if r == utf8.RuneError && n == 1 {
+		return 0, p.errorf(start, "invalid UTF-8")
+	}
+	p.pos += n
+	if r != '\\' {
+		return r, nil
+	}
+	if p.pos == len(p.spec) {
+		return 0, p.errorf(start, "trailing backslash")
+	}
+	r, n = utf8.DecodeRuneInString(p.spec[p.pos:])
+	p.pos += n
+	switch {
+	case r == 't':
+		return '\t', nil
+	case r == 'n':
+		return '\n', nil
+	case r == 'r':
+		return '\r', nil
+	case r < utf8.RuneSelf && !isAlnum(byte(r)):
+		return r, nil
+	}
+	return 0, p.errorf(start, "unknown escape sequence \\%c", r)
+}
+
+func isAlnum(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
@@ -0,0 +1,102 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+func TestRanges(t *testing.T) {
+	for _, test := range []struct {
+		spec    string
+		members string
+		non     string
+	}{
+		{"a-zA-Z0-9_", "azAZ09_m", "-^`{é"},
+		{"-a", "-a", "b"},
+		{"a-", "a-", "b"},
+		{`\--/`, "-./", ",0"},
+		{`\\\t\]`, "\\\t]", "t"},
+		{"α-ω", "αβω", "a"},
+		{"", "", "a"},
+	} {
+		s, err := Ranges(test.spec)
+		if err != nil {
+			t.Errorf("%q: %v", test.spec, err)
+			continue
+		}
+		for _, r := range test.members {
+			if !s.Contains(r) {
+				t.Errorf("%q does not contain %q", test.spec, r)
+			}
+		}
+		for _, r := range test.non {
+			if s.Contains(r) {
+				t.Errorf("%q contains %q", test.spec, r)
+			}
+		}
+	}
+
+	for _, test := range []struct {
+		spec   string
+		offset int
+	}{
+		{"az-a", 1},
+		{`ab\`, 2},
+		{`a\q`, 1},
+		{"a\xff", 1},
+		{"a[:alpha", 1},
+		{"[:alpha:][:letter:]", 9},
+		{"0-[:alpha:]", 2},
+	} {
+		_, err := Ranges(test.spec)
+		if e, ok := err.(*RangeError); !ok || e.Offset != test.offset {
+			t.Errorf("%q: error %v, want offset %d", test.spec, err, test.offset)
+		}
+	}
+
+	l := New(lexSparse, "snake_case2 x")
+	if n := l.AcceptRunSet(MustRanges("a-z0-9_")); n != 11 {
+		t.Errorf("accepted %d runes", n)
+	}
+}
+
+func TestRangesClasses(t *testing.T) {
+	for _, test := range []struct {
+		spec    string
+		ascii   bool
+		members string
+		non     string
+	}{
+		{"[:alpha:]_", false, "aZé_", "0-"},
+		{"[:alpha:]_", true, "aZ_", "é0-"},
+		{"[:digit:][:space:]", false, "09 \t\u0663\u00a0", "a"},
+		{"[:digit:][:space:]", true, "09 \t", "\u0663\u00a0a"},
+		{"[:punct:]", true, `!/:@[\]^_{}~` + "`", "a0 "},
+		{"[:xdigit:]x", false, "09afAFx", "gG"},
+		{"[:upper:]-", false, "AZÉ-", "aé"},
+		{`\[:a`, false, "[:a", "b"},
+	} {
+		parse := Ranges
+		if test.ascii {
+			parse = ASCIIRanges
+		}
+		s, err := parse(test.spec)
+		if err != nil {
+			t.Errorf("%q: %v", test.spec, err)
+			continue
+		}
+		for _, r := range test.members {
+			if !s.Contains(r) {
+				t.Errorf("%q (ascii %v) does not contain %q", test.spec, test.ascii, r)
+			}
+		}
+		for _, r := range test.non {
+			if s.Contains(r) {
+				t.Errorf("%q (ascii %v) contains %q", test.spec, test.ascii, r)
+			}
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// OpKind identifies an operation recorded by a Recorder.
+type OpKind uint8
+
+const (
+	OpAdvance OpKind = iota // runes read with Advance
+	OpBackup                // Backup
+	OpJump                  // any other change of position, as by AdvanceByte
+	OpIgnore                // Ignore
+	OpEmit                  // an item emitted for the current lexeme
+	OpError                 // an error or warning
+	OpMarker                // EmitMarker
+	OpInject                // InjectItem
+	OpRestore               // Restore
+	OpState                 // a transition to a new StateFn
+)
+
+var opNames = [...]string{"advance", "backup", "jump", "ignore", "emit", "error", "marker", "inject", "restore", "state"}
+
+// String returns the lower case name of k.
+func (k OpKind) String() string {
+	if int(k) < len(opNames) {
+		return opNames[k]
+	}
+	return "OpKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// MarshalText returns the name of k.
+func (k OpKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText sets k to the OpKind named by text.
+func (k *OpKind) UnmarshalText(text []byte) error {
+	for i, name := range opNames {
+		if name == string(text) {
+			*k = OpKind(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown operation %q", text)
+}
+
+// An Op is an operation recorded by a Recorder.
+type Op struct {
+	Kind  OpKind   `json:"kind"`
+	Pos   int      `json:"pos"`             // position after the operation
+	Start int      `json:"start,omitempty"` // lexeme start for OpEmit, OpIgnore and OpRestore
+	N     int      `json:"n,omitempty"`     // runes read by OpAdvance, width of the last rune for OpJump, or items discarded by OpRestore
+	Type  ItemType `json:"type,omitempty"`  // item type for OpEmit, OpError, OpMarker and OpInject
+	Text  string   `json:"text,omitempty"`  // message, injected value or state name
+}
+
+// String returns a compact description of op.
+func (op Op) String() string {
+	switch op.Kind {
+	case OpAdvance:
+		return fmt.Sprintf("advance %d @%d", op.N, op.Pos)
+	case OpEmit, OpIgnore, OpRestore:
+		return fmt.Sprintf("%v %d [%d,%d)", op.Kind, op.Type, op.Start, op.Pos)
+	case OpError, OpInject:
+		return fmt.Sprintf("%v %d @%d %q", op.Kind, op.Type, op.Pos, op.Text)
+	case OpMarker:
+		return fmt.Sprintf("marker %d @%d", op.Type, op.Pos)
+	case OpState:
+		return "state " + op.Text
+	}
+	return fmt.Sprintf("%v @%d", op.Kind, op.Pos)
+}
+
+// A Recorder logs the operations of a lexer created with WithRecorder so that
+// they can be inspected or re-executed with Replay.  Consecutive runes read
+// are recorded as a single OpAdvance.  Payloads, attributes and metadata of
+// emitted items are not recorded.
+type Recorder struct {
+	ops []Op
+}
+
+// Ops returns the operations recorded by r.
+func (r *Recorder) Ops() []Op {
+	return r.ops
+}
+
+// Reset discards the operations recorded by r.
+func (r *Recorder) Reset() {
+	r.ops = r.ops[:0]
+}
+
+// String returns the operations of r, one per line.
+func (r *Recorder) String() string {
+	var b strings.Builder
+	for _, op := range r.ops {
+		b.WriteString(op.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func (r *Recorder) record(op Op) {
+	r.ops = append(r.ops, op)
+}
+
+func (r *Recorder) advance(pos int) {
+	if n := len(r.ops); n > 0 && r.ops[n-1].Kind == OpAdvance {
+		r.ops[n-1].N++
+		r.ops[n-1].Pos = pos
+		return
+	}
+	r.record(Op{Kind: OpAdvance, Pos: pos, N: 1})
+}
+
+// stateName returns the name of fn in States, or the name of the function fn
+// if it is not registered.
+func stateName(fn StateFn) string {
+	if fn == nil {
+		return "nil"
+	}
+	if name, ok := States.Name(fn); ok {
+		return name
+	}
+	f := runtime.FuncForPC(statePC(fn))
+	if f == nil {
+		return "?"
+	}
+	return f.Name()
+}
+
+// Replay re-executes the operations in ops against input without running any
+// state functions and returns the items emitted, including errors.  The
+// options given should be those of the recorded lexer.  Replay returns an
+// error describing the first operation whose outcome differs from the
+// recording, which happens if input is not the recorded input.  Lexers that
+// use PushInput cannot be replayed, and a WithRecorder option is ignored.
+func Replay(input string, ops []Op, opts ...Option) ([]*Item, error) {
+	l := New(func(*Lexer) StateFn { return nil }, input, opts...)
+	l.rec = nil
+	l.setSlow()
+	for k, op := range ops {
+		switch op.Kind {
+		case OpAdvance:
+			for i := 0; i < op.N; i++ {
+				l.Advance()
+			}
+		case OpBackup:
+			l.Backup()
+		case OpJump:
+			if op.Pos < op.N || op.Pos > len(l.input) {
+				return l.items, fmt.Errorf("op %d: %v: position out of range", k, op)
+			}
+			l.pos, l.width = op.Pos, op.N
+			l.last, _ = utf8.DecodeLastRuneInString(l.input[op.Pos-op.N : op.Pos])
+		case OpIgnore:
+			l.start = op.Start
+			l.Ignore()
+		case OpEmit:
+			l.start = op.Start
+			l.Emit(op.Type)
+		case OpError:
+			i := &Item{Type: op.Type, Pos: op.Pos, Value: op.Text}
+			if op.Type == ItemError {
+				l.error(i)
+			} else {
+				l.enqueue(i)
+			}
+			continue
+		case OpMarker:
+			l.EmitMarker(op.Type)
+		case OpInject:
+			l.InjectItem(Item{Type: op.Type, Value: op.Text})
+		case OpRestore:
+			if op.N > len(l.items) {
+				return l.items, fmt.Errorf("op %d: %v: item count out of range", k, op)
+			}
+			l.items = l.items[:len(l.items)-op.N]
+			l.pos, l.start = op.Pos, op.Start
+		case OpState:
+			continue
+		}
+		if l.pos != op.Pos {
+			return l.items, fmt.Errorf("op %d: %v: replay reached position %d", k, op, l.pos)
+		}
+	}
+	l.atEOF()
+	return l.items, nil
+}
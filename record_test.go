@@ -0,0 +1,81 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"strings"
+	"testing"
+)
+
+func lexRecorded(l *Lexer) StateFn {
+	l.IgnoreRun(" ")
+	s := l.Save()
+	switch c, n := l.Advance(); {
+	case n == 0:
+		return nil
+	case c == '!':
+		l.Warnf("bang")
+		l.Ignore()
+	case c == '?':
+		return l.Errorf("question")
+	case c == 'é':
+		l.Restore(s)
+		l.AcceptString("é")
+		l.EmitMarker(3)
+		l.Emit(2)
+	default:
+		l.AcceptRunNotAny(" !?é")
+		l.Backup()
+		l.Advance()
+		l.Emit(1)
+	}
+	return lexRecorded
+}
+
+func TestRecorderReplay(t *testing.T) {
+	const input = "ab é! c ?"
+	rec := new(Recorder)
+	want := Collect(New(lexRecorded, input, WithRecorder(rec)))
+	if !strings.Contains(rec.String(), "state github.com/bmatsuo/go-lexer.lexRecorded\n") {
+		t.Errorf("missing state transition in trace:\n%s", rec)
+	}
+	got, err := Replay(input, rec.Ops())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("replayed %d items, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Type != want[i].Type || got[i].Pos != want[i].Pos || got[i].Value != want[i].Value {
+			t.Errorf("item %d: replayed %v %q at %d, want %v %q at %d",
+				i, got[i].Type, got[i].Value, got[i].Pos, want[i].Type, want[i].Value, want[i].Pos)
+		}
+	}
+}
+
+func TestRecorderCompact(t *testing.T) {
+	rec := new(Recorder)
+	l := New(lexRecorded, "abc", WithRecorder(rec))
+	l.Advance()
+	l.Advance()
+	l.Advance()
+	l.Backup()
+	if ops := rec.Ops(); len(ops) != 2 || ops[0].String() != "advance 3 @3" || ops[1].String() != "backup @2" {
+		t.Errorf("unexpected trace %v", ops)
+	}
+	rec.Reset()
+	if len(rec.Ops()) != 0 {
+		t.Errorf("Reset left %d ops", len(rec.Ops()))
+	}
+}
+
+func TestReplayDiverges(t *testing.T) {
+	rec := new(Recorder)
+	Collect(New(lexRecorded, "ab é c", WithRecorder(rec)))
+	if _, err := Replay("ab e cd", rec.Ops()); err == nil {
+		t.Errorf("replay against different input succeeded")
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"strings"
+)
+
+// A Recovery repairs a lexer after an error so that lexing can continue, and
+// returns true if it succeeded.  A Recovery that fails leaves the lexer
+// unchanged.  Recoveries are applied with Recover.
+type Recovery func(*Lexer) bool
+
+// Recover applies the first of rs that succeeds and returns next, so that a
+// state function can report an error and continue:
+//
+//	l.Errorf("unexpected %q", c)
+//	return l.Recover(lexStatement, lexer.SkipTo(";\n"), lexer.DeleteRune())
+//
+// Recover returns nil if none of rs succeeds or if l is halting because the
+// limit of WithErrorLimit was reached.
+func (l *Lexer) Recover(next StateFn, rs ...Recovery) StateFn {
+	if l.halt {
+		return nil
+	}
+	for _, r := range rs {
+		if r(l) {
+			return next
+		}
+	}
+	return nil
+}
+
+// SkipTo returns a panic-mode Recovery that discards the current lexeme and
+// the input preceding the next rune in sync, a synchronizing set such as
+// statement terminators.  The synchronizing rune is not consumed.  The
+// Recovery fails if no rune in sync follows.
+func SkipTo(sync string) Recovery {
+	return func(l *Lexer) bool {
+		n := strings.IndexAny(l.input[l.pos:], sync)
+		if n < 0 {
+			return false
+		}
+		l.skip(n)
+		l.Ignore()
+		return true
+	}
+}
+
+// SkipToSet is like SkipTo for the synchronizing set s.
+func SkipToSet(s *RuneSet) Recovery {
+	return func(l *Lexer) bool {
+		n := strings.IndexFunc(l.input[l.pos:], s.Contains)
+		if n < 0 {
+			return false
+		}
+		l.skip(n)
+		l.Ignore()
+		return true
+	}
+}
+
+// SkipToEnd returns a Recovery that discards the rest of the input.
+func SkipToEnd() Recovery {
+	return func(l *Lexer) bool {
+		l.skip(len(l.input) - l.pos)
+		l.Ignore()
+		return true
+	}
+}
+
+// DeleteRune returns a Recovery that discards the current lexeme and the
+// rune following it, the offending character in most errors.  The Recovery
+// fails at the end of the input.
+func DeleteRune() Recovery {
+	return func(l *Lexer) bool {
+		if _, n := l.Advance(); n == 0 {
+			return false
+		}
+		l.Ignore()
+		return true
+	}
+}
+
+// Insert returns a Recovery that inserts a synthetic item of type t with
+// the given value at l's position, such as a missing closing delimiter, as
+// with InjectItem.  The current lexeme is not disturbed.
+func Insert(t ItemType, value string) Recovery {
+	return func(l *Lexer) bool {
+		l.InjectItem(Item{Type: t, Value: value})
+		return true
+	}
+}
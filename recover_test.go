@@ -0,0 +1,70 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLexerRecover(t *testing.T) {
+	const (
+		itemWord ItemType = iota + 1
+		itemSemi
+		itemString
+		itemQuote
+	)
+	var state StateFn
+	state = func(l *Lexer) StateFn {
+		l.IgnoreRun(" \n")
+		switch c, n := l.Advance(); {
+		case n == 0:
+			return nil
+		case c == ';':
+			l.Emit(itemSemi)
+		case c == '"':
+			l.AcceptRunNotAny("\"\n")
+			if !l.Accept(`"`) {
+				l.Errorf("unterminated string")
+				l.Emit(itemString)
+				return l.Recover(state, Insert(itemQuote, `"`))
+			}
+			l.Emit(itemString)
+		case 'a' <= c && c <= 'z':
+			l.AcceptRun("abcdefghijklmnopqrstuvwxyz")
+			l.Emit(itemWord)
+		case c == '!':
+			l.Errorf("unexpected %q", c)
+			return l.Recover(state, SkipTo(";"), SkipToEnd())
+		default:
+			l.Backup()
+			l.Errorf("unexpected %q", c)
+			return l.Recover(state, DeleteRune())
+		}
+		return state
+	}
+	l := New(state, `a ! b; c ? d "e`+"\n"+`f ! g h`)
+	var got []string
+	for item := l.Next(); item.Type != ItemEOF; item = l.Next() {
+		got = append(got, fmt.Sprintf("%d:%s", item.Type, item))
+	}
+	want := fmt.Sprint([]string{
+		"1:a", fmt.Sprintf("%d:unexpected '!'", ItemError), "2:;",
+		"1:c", fmt.Sprintf("%d:unexpected '?'", ItemError), "1:d",
+		fmt.Sprintf("%d:unterminated string", ItemError), `3:"e`, `4:"`,
+		"1:f", fmt.Sprintf("%d:unexpected '!'", ItemError),
+	})
+	if fmt.Sprint(got) != want {
+		t.Errorf("items\n%v\nwant\n%v", got, want)
+	}
+
+	l = New(state, "!!", WithErrorCollection(), WithErrorLimit(1))
+	if item := l.Next(); item.Value != "too many errors" {
+		t.Errorf("item %v", item)
+	}
+	if item := l.Next(); item.Type != ItemEOF {
+		t.Errorf("item %v after the error limit", item)
+	}
+}
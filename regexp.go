@@ -0,0 +1,78 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"reflect"
+	"regexp"
+	"sync"
+)
+
+// maxAnchored is the number of anchored expressions cached by AcceptRegexp.
+const maxAnchored = 256
+
+// anchored caches the anchored copies of expressions given to AcceptRegexp,
+// shared by all lexers.  Lookups do not lock; insertions and evictions hold
+// mu so that n counts the entries of m.
+var anchored struct {
+	mu sync.Mutex
+	n  int
+	m  sync.Map // *regexp.Regexp to its anchored copy
+}
+
+// AcceptRegexp advances l's position over the match of re beginning at the
+// current position.  AcceptRegexp returns true if l advanced, empty matches
+// are not accepted.
+//
+// An anchored copy of re is compiled the first time any lexer uses re and
+// cached for subsequent calls, and it matches leftmost-longest if re does.
+// The expression is matched against the remaining input only, so assertions
+// like \b do not see the text preceding the current position.
+func (l *Lexer) AcceptRegexp(re *regexp.Regexp) bool {
+	loc := anchor(re).FindStringIndex(l.input[l.pos:])
+	if loc == nil || loc[0] != 0 || loc[1] == 0 {
+		return false
+	}
+	l.skip(loc[1])
+	return true
+}
+
+// anchor returns re anchored at the beginning of its input.  Every expression
+// is wrapped, since a leading ^ or \A need not anchor all alternatives, as in
+// ^a|b.  When the cache is full an arbitrary entry is evicted.
+func anchor(re *regexp.Regexp) *regexp.Regexp {
+	if a, ok := anchored.m.Load(re); ok {
+		return a.(*regexp.Regexp)
+	}
+	a := regexp.MustCompile(`\A(?:` + re.String() + `)`)
+	if isLongest(re) {
+		a.Longest()
+	}
+	anchored.mu.Lock()
+	defer anchored.mu.Unlock()
+	if v, loaded := anchored.m.LoadOrStore(re, a); loaded {
+		return v.(*regexp.Regexp)
+	}
+	anchored.n++
+	if anchored.n > maxAnchored {
+		anchored.m.Range(func(k, _ interface{}) bool {
+			if k == re {
+				return true
+			}
+			anchored.m.Delete(k)
+			anchored.n--
+			return false
+		})
+	}
+	return a
+}
+
+// isLongest reports whether re matches leftmost-longest, as set by Longest or
+// CompilePOSIX.  The regexp package does not export the setting, so it is read
+// from the unexported field.
+func isLongest(re *regexp.Regexp) bool {
+	f := reflect.ValueOf(re).Elem().FieldByName("longest")
+	return f.IsValid() && f.Kind() == reflect.Bool && f.Bool()
+}
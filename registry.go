@@ -0,0 +1,91 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// A Registry names StateFns, so that tools which record states, such as
+// Bundle, Recorder, Step, StateGraph and Tracer, report stable names instead
+// of the names of Go functions, and so that states can be found again by
+// name.  A StateFn is identified by its code: closures created by the same
+// function literal share a name.  A Registry is safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	states map[string]StateFn
+	names  map[uintptr]string
+}
+
+// States is the registry consulted for state names throughout the package.
+// Lexer packages typically add their states to it in an init function.
+var States = NewRegistry()
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		states: make(map[string]StateFn),
+		names:  make(map[uintptr]string),
+	}
+}
+
+// RegisterState adds fn to States under name and returns fn.
+func RegisterState(name string, fn StateFn) StateFn {
+	return States.Register(name, fn)
+}
+
+// Register adds fn to r under name and returns fn.  Register panics if fn is
+// nil or if name is already registered.
+func (r *Registry) Register(name string, fn StateFn) StateFn {
+	if fn == nil {
+		panic(fmt.Sprintf("lexer: register nil state %q", name))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.states[name]; ok {
+		panic(fmt.Sprintf("lexer: state %q registered twice", name))
+	}
+	r.states[name] = fn
+	r.names[statePC(fn)] = name
+	return fn
+}
+
+// Lookup returns the state registered under name.
+func (r *Registry) Lookup(name string) (StateFn, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fn, ok := r.states[name]
+	return fn, ok
+}
+
+// Name returns the name under which fn is registered.
+func (r *Registry) Name(fn StateFn) (string, bool) {
+	if fn == nil {
+		return "", false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	name, ok := r.names[statePC(fn)]
+	return name, ok
+}
+
+// Names returns the registered names in sorted order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.states))
+	for name := range r.states {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+func statePC(fn StateFn) uintptr {
+	return reflect.ValueOf(fn).Pointer()
+}
@@ -0,0 +1,51 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+	"testing"
+)
+
+func lexRegistryA(l *Lexer) StateFn { return nil }
+func lexRegistryB(l *Lexer) StateFn { return nil }
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	r.Register("b", lexRegistryB)
+	r.Register("a", lexRegistryA)
+	if fn, ok := r.Lookup("a"); !ok || statePC(fn) != statePC(lexRegistryA) {
+		t.Errorf("lookup of a failed")
+	}
+	if _, ok := r.Lookup("c"); ok {
+		t.Errorf("lookup of c succeeded")
+	}
+	if name, ok := r.Name(lexRegistryB); !ok || name != "b" {
+		t.Errorf("name %q", name)
+	}
+	if _, ok := r.Name(lexSparse); ok {
+		t.Errorf("unregistered state named")
+	}
+	if names := fmt.Sprint(r.Names()); names != "[a b]" {
+		t.Errorf("names %s", names)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("no panic for a duplicate name")
+		}
+	}()
+	r.Register("a", lexRegistryB)
+}
+
+func TestStateNameRegistered(t *testing.T) {
+	fn := func(l *Lexer) StateFn { return nil }
+	if name := stateName(fn); name == "registered" {
+		t.Fatalf("name %q", name)
+	}
+	RegisterState("registered", fn)
+	if name := stateName(fn); name != "registered" {
+		t.Errorf("name %q", name)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A Remainder reads the input of a Lexer following the position at which it
+// was created, so that the rest of a lexeme can be handed to another decoder
+// such as strconv or encoding/json.  Reading from a Remainder does not move
+// the Lexer; Commit or Lexer.Commit does.  A Remainder reads the raw input,
+// ignoring any Transform or Decoder of the Lexer.
+type Remainder struct {
+	*strings.Reader
+	l   *Lexer
+	pos int
+}
+
+// Remaining returns a Remainder reading l's input from its position.
+func (l *Lexer) Remaining() *Remainder {
+	return &Remainder{Reader: strings.NewReader(l.input[l.pos:]), l: l, pos: l.pos}
+}
+
+// Consumed returns the number of bytes read from r.
+func (r *Remainder) Consumed() int {
+	return int(r.Size()) - r.Len()
+}
+
+// Commit advances the Lexer past the bytes read from r, adding them to the
+// current lexeme.  Commit panics if the Lexer has moved since r was created.
+func (r *Remainder) Commit() {
+	if r.l.pos != r.pos {
+		panic("lexer: commit of a stale Remainder")
+	}
+	r.l.Commit(r.Consumed())
+}
+
+// Commit advances l's position n bytes, adding them to the current lexeme, to
+// account for input consumed by another reader, such as the InputOffset of a
+// json.Decoder reading from Remaining.  The bytes should end on a rune
+// boundary.  Commit panics if n is negative or exceeds the remaining input.
+func (l *Lexer) Commit(n int) {
+	if n < 0 || n > len(l.input)-l.pos {
+		panic(fmt.Sprintf("lexer: commit of %d bytes with %d remaining", n, len(l.input)-l.pos))
+	}
+	l.skip(n)
+}
+
+// TailReader returns a reader of l's remaining input that advances l as it is
+// read, so that a large embedded payload can be streamed to a writer without
+// being copied into a string.  The bytes read are added to the current lexeme
+// as by Commit; a state function typically calls Ignore after reading them,
+// or emits them as an item.  The reader implements io.WriterTo, and reads
+// the raw input, ignoring any Transform or Decoder of l.
+func (l *Lexer) TailReader() io.Reader {
+	return tailReader{l}
+}
+
+type tailReader struct {
+	l *Lexer
+}
+
+func (t tailReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	n := copy(p, t.l.input[t.l.pos:])
+	if n == 0 {
+		return 0, io.EOF
+	}
+	t.l.skip(n)
+	return n, nil
+}
+
+func (t tailReader) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, t.l.input[t.l.pos:])
+	t.l.skip(n)
+	return int64(n), err
+}
@@ -0,0 +1,99 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRemaining(t *testing.T) {
+	var start StateFn
+	start = func(l *Lexer) StateFn {
+		if !l.AcceptString("=") {
+			return nil
+		}
+		l.Ignore()
+		dec := json.NewDecoder(l.Remaining())
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return l.Errorf("%v", err)
+		}
+		l.Commit(int(dec.InputOffset()))
+		l.EmitWith(1, v)
+		return start
+	}
+	items := Collect(New(start, `={"a": [1, 2]}="é"`))
+	if len(items) != 2 || items[0].Value != `{"a": [1, 2]}` || items[1].Value != `"é"` || items[1].Pos != 15 {
+		t.Fatalf("unexpected items %v", items)
+	}
+	if s, ok := PayloadOf[string](items[1]); !ok || s != "é" {
+		t.Errorf("unexpected payload %v", items[1].Payload)
+	}
+}
+
+func TestRemainderCommit(t *testing.T) {
+	l := New(func(*Lexer) StateFn { return nil }, "abλc")
+	l.Advance()
+	r := l.Remaining()
+	var _ io.RuneScanner = r
+	if c, _, _ := r.ReadRune(); c != 'b' {
+		t.Fatalf("read %q", c)
+	}
+	r.ReadRune()
+	r.Commit()
+	if l.Current() != "abλ" {
+		t.Errorf("current %q", l.Current())
+	}
+	if c, n := l.Last(); c != 'λ' || n != 2 {
+		t.Errorf("last %q %d", c, n)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("no panic committing a stale Remainder")
+		}
+	}()
+	r.Commit()
+}
+
+func TestTailReader(t *testing.T) {
+	payload := strings.Repeat("lexer", 100)
+	input := "data: " + base64.StdEncoding.EncodeToString([]byte(payload)) + "\nend"
+	var out bytes.Buffer
+	l := New(func(l *Lexer) StateFn {
+		l.AcceptString("data: ")
+		l.Ignore()
+		dec := base64.NewDecoder(base64.StdEncoding, io.LimitReader(l.TailReader(), int64(strings.IndexByte(input, '\n')-l.Pos())))
+		if _, err := io.Copy(&out, dec); err != nil {
+			return l.Errorf("%v", err)
+		}
+		l.Ignore()
+		l.AcceptString("\n")
+		l.Ignore()
+		l.AcceptRunNotAny("")
+		l.Emit(1)
+		return nil
+	}, input)
+	if item := l.Next(); item.Value != "end" {
+		t.Errorf("unexpected item %v", item)
+	}
+	if out.String() != payload {
+		t.Errorf("payload %q", out.String())
+	}
+
+	out.Reset()
+	l = New(func(*Lexer) StateFn { return nil }, "abc")
+	l.Advance()
+	if n, err := io.Copy(&out, l.TailReader()); n != 2 || err != nil || out.String() != "bc" {
+		t.Errorf("copied %d %q: %v", n, out.String(), err)
+	}
+	if l.Current() != "abc" {
+		t.Errorf("current %q", l.Current())
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// A remap records a span of the original input rewritten by a Transform.
+type remap struct {
+	orig   int // offset of the span in the original input
+	width  int // length of the span in the original input
+	stream int // offset of the replacement in the transformed text
+	n      int // length of the replacement
+}
+
+// StreamOffset maps offset in the original text of s to the corresponding
+// offset in the text produced by the Transform given to WithTransform.  An
+// offset inside a rewritten span maps to the start of its replacement.  If s
+// has no Transform StreamOffset returns offset.
+func (s *Source) StreamOffset(offset int) int {
+	s.remapOnce.Do(s.remap)
+	i := sort.Search(len(s.remaps), func(i int) bool { return s.remaps[i].orig > offset }) - 1
+	if i < 0 {
+		return offset
+	}
+	m := s.remaps[i]
+	if offset < m.orig+m.width {
+		return m.stream
+	}
+	return m.stream + m.n + offset - m.orig - m.width
+}
+
+// OriginalOffset maps offset in the transformed text of s to the
+// corresponding offset in the original text.  It is the inverse of
+// StreamOffset for offsets that are not inside a rewritten span.
+func (s *Source) OriginalOffset(offset int) int {
+	s.remapOnce.Do(s.remap)
+	i := sort.Search(len(s.remaps), func(i int) bool { return s.remaps[i].stream > offset }) - 1
+	if i < 0 {
+		return offset
+	}
+	m := s.remaps[i]
+	if offset < m.stream+m.n {
+		return m.orig
+	}
+	return m.orig + m.width + offset - m.stream - m.n
+}
+
+// remap indexes the spans of s rewritten by its transform.
+func (s *Source) remap() {
+	if s.transform == nil {
+		return
+	}
+	stream := 0
+	for pos := 0; pos < len(s.text); {
+		r, w, ok := s.transform(s.text, pos)
+		if !ok {
+			_, w = utf8.DecodeRuneInString(s.text[pos:])
+			pos += w
+			stream += w
+			continue
+		}
+		n := utf8.RuneLen(r)
+		if r == EOF && pos+w == len(s.text) {
+			n = 0
+		}
+		s.remaps = append(s.remaps, remap{pos, w, stream, n})
+		pos += w
+		stream += n
+	}
+}
+
+// Span returns the range of the original input covered by i, [start, end).
+func (i *Item) Span() (start, end int) {
+	return i.Pos, i.End()
+}
+
+// StreamPos returns the offset of i in the text produced by the Transform
+// given to WithTransform.  Without a Transform StreamPos returns i.Pos.
+func (i *Item) StreamPos() int {
+	if i.src == nil {
+		return i.Pos
+	}
+	return i.src.StreamOffset(i.Pos)
+}
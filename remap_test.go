@@ -0,0 +1,57 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+func TestItemSpan(t *testing.T) {
+	input := "ab\\\ncd e\\\r\n\\\nf g\\\n"
+	items := Collect(New(lexSplicedWords, input, WithTransform(SpliceLines)))
+	want := []struct {
+		start, end int
+		stream     int
+	}{
+		{0, 6, 0},
+		{7, 14, 5},
+		{15, 18, 8},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d: %v", len(items), len(want), items)
+	}
+	for i, w := range want {
+		start, end := items[i].Span()
+		if start != w.start || end != w.end {
+			t.Errorf("item %d: span [%d, %d), want [%d, %d)", i, start, end, w.start, w.end)
+		}
+		if pos := items[i].StreamPos(); pos != w.stream {
+			t.Errorf("item %d: stream position %d, want %d", i, pos, w.stream)
+		}
+	}
+}
+
+func TestSourceOffsets(t *testing.T) {
+	l := New(lexSplicedWords, "ab\\\ncd", WithTransform(SpliceLines))
+	src := l.Source()
+	for _, test := range []struct{ orig, stream int }{
+		{0, 0}, {1, 1}, {2, 2}, {4, 2}, {5, 3}, {6, 4},
+	} {
+		if off := src.StreamOffset(test.orig); off != test.stream {
+			t.Errorf("StreamOffset(%d) = %d, want %d", test.orig, off, test.stream)
+		}
+	}
+	for _, test := range []struct{ stream, orig int }{
+		{0, 0}, {1, 1}, {2, 2}, {3, 5}, {4, 6},
+	} {
+		if off := src.OriginalOffset(test.stream); off != test.orig {
+			t.Errorf("OriginalOffset(%d) = %d, want %d", test.stream, off, test.orig)
+		}
+	}
+	plain := New(lexSplicedWords, "abc").Source()
+	if off := plain.StreamOffset(2); off != 2 {
+		t.Errorf("StreamOffset without transform = %d", off)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"bufio"
+	"html"
+	"io"
+)
+
+// WriteHTML writes input to w as HTML with the text of each item wrapped in a
+// <span> element whose class is given by classes.  Items with types missing
+// from classes, and text between items, are written without a wrapper.  The
+// original text of input is preserved exactly (though escaped).
+//
+// Items must be given in input order, as returned by Collect.  Error, warning,
+// and EOF items are ignored.
+func WriteHTML(w io.Writer, input string, items []*Item, classes map[ItemType]string) error {
+	bw := bufio.NewWriter(w)
+	spans(input, items, func(text string, item *Item) {
+		class, ok := "", false
+		if item != nil {
+			class, ok = classes[item.Type]
+		}
+		if !ok {
+			bw.WriteString(html.EscapeString(text))
+			return
+		}
+		bw.WriteString(`<span class="`)
+		bw.WriteString(html.EscapeString(class))
+		bw.WriteString(`">`)
+		bw.WriteString(html.EscapeString(text))
+		bw.WriteString(`</span>`)
+	})
+	return bw.Flush()
+}
+
+// A Palette maps item types to ANSI SGR parameters, for example "31" for red
+// text or "1;34" for bold blue text.
+type Palette map[ItemType]string
+
+// WriteANSI writes input to w with the text of each item colored according
+// to palette, for display in a terminal.  Items with types missing from
+// palette, and text between items, are written uncolored.  Items are
+// interpreted as by WriteHTML.
+func WriteANSI(w io.Writer, input string, items []*Item, palette Palette) error {
+	bw := bufio.NewWriter(w)
+	spans(input, items, func(text string, item *Item) {
+		sgr, ok := "", false
+		if item != nil {
+			sgr, ok = palette[item.Type]
+		}
+		if !ok {
+			bw.WriteString(text)
+			return
+		}
+		bw.WriteString("\x1b[")
+		bw.WriteString(sgr)
+		bw.WriteString("m")
+		bw.WriteString(text)
+		bw.WriteString("\x1b[0m")
+	})
+	return bw.Flush()
+}
+
+// spans calls fn for consecutive spans of input.  The item covering each span
+// is passed to fn, or nil if the span is text between items.  Items that are
+// not text, or that overlap a previous item, are skipped.
+func spans(input string, items []*Item, fn func(text string, item *Item)) {
+	off := 0
+	for _, item := range items {
+		switch item.Type {
+		case ItemEOF, ItemError, ItemWarning:
+			continue
+		}
+		lo, hi := item.Pos, item.End()
+		if lo < off || hi > len(input) || lo == hi {
+			continue
+		}
+		if lo > off {
+			fn(input[off:lo], nil)
+		}
+		fn(input[lo:hi], item)
+		off = hi
+	}
+	if off < len(input) {
+		fn(input[off:], nil)
+	}
+}
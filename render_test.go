@@ -0,0 +1,58 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"bytes"
+	"testing"
+)
+
+const (
+	renderIdent ItemType = iota
+	renderOp
+)
+
+func renderTestItems(input string) []*Item {
+	var start StateFn
+	start = func(l *Lexer) StateFn {
+		l.IgnoreRun(" ")
+		switch {
+		case l.AcceptRun("abc") > 0:
+			l.Emit(renderIdent)
+		case l.AcceptRun("<>&") > 0:
+			l.Emit(renderOp)
+		default:
+			return nil
+		}
+		return start
+	}
+	return Collect(New(start, input))
+}
+
+func TestWriteHTML(t *testing.T) {
+	const input = "a < b && c?"
+	var buf bytes.Buffer
+	err := WriteHTML(&buf, input, renderTestItems(input), map[ItemType]string{renderOp: "op"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `a <span class="op">&lt;</span> b <span class="op">&amp;&amp;</span> c?`
+	if buf.String() != want {
+		t.Errorf("got %q want %q", buf.String(), want)
+	}
+}
+
+func TestWriteANSI(t *testing.T) {
+	const input = "a <b"
+	var buf bytes.Buffer
+	err := WriteANSI(&buf, input, renderTestItems(input), Palette{renderIdent: "1;34"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\x1b[1;34ma\x1b[0m <\x1b[1;34mb\x1b[0m"
+	if buf.String() != want {
+		t.Errorf("got %q want %q", buf.String(), want)
+	}
+}
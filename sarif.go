@@ -0,0 +1,156 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// A SARIF encodes lexer errors and warnings as a SARIF 2.1.0 log, the format
+// ingested by code scanning services and editors.  The fields other than
+// RuleID describe the tool that reports the errors.
+type SARIF struct {
+	Name           string
+	Version        string
+	InformationURI string
+
+	// RuleID returns the rule of an error.  If RuleID is nil the rule is the
+	// name of the error's item type, as given to WithTypeNames.
+	RuleID func(*Error) string
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	RuleIndex *int            `json:"ruleIndex,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysical `json:"physicalLocation"`
+}
+
+type sarifPhysical struct {
+	ArtifactLocation *sarifArtifact `json:"artifactLocation,omitempty"`
+	Region           sarifRegion    `json:"region"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// Write writes a SARIF log with one run containing a result for each of
+// errs, such as the errors returned by Lexer.Errors, to w.  The region of a
+// result is the rune at the position of its error, with columns counted in
+// Unicode code points.  Errors that are not *Error values have no location
+// or rule.
+func (s *SARIF) Write(w io.Writer, errs []error) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           s.Name,
+			Version:        s.Version,
+			InformationURI: s.InformationURI,
+		}},
+		ColumnKind: "unicodeCodePoints",
+		Results:    []sarifResult{},
+	}
+	rules := make(map[string]int)
+	for _, err := range errs {
+		res := sarifResult{Level: "error", Message: sarifMessage{err.Error()}}
+		var e *Error
+		if errors.As(err, &e) {
+			res = s.result(e)
+			i, ok := rules[res.RuleID]
+			if !ok {
+				i = len(run.Tool.Driver.Rules)
+				rules[res.RuleID] = i
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{res.RuleID})
+			}
+			res.RuleIndex = &i
+		}
+		run.Results = append(run.Results, res)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+func (s *SARIF) result(e *Error) sarifResult {
+	res := sarifResult{Level: "error", Message: sarifMessage{e.Message()}}
+	if e.Severity() == SeverityWarning {
+		res.Level = "warning"
+	}
+	if s.RuleID != nil {
+		res.RuleID = s.RuleID(e)
+	} else {
+		res.RuleID = (*Item)(e).TypeName()
+	}
+	if e.src == nil {
+		return res
+	}
+	// Columns of the Position may count display cells.
+	start := e.Position()
+	lo, _ := e.src.line(start.Line)
+	col := utf8.RuneCountInString(e.src.text[lo:start.Offset]) + 1
+	loc := sarifLocation{PhysicalLocation: sarifPhysical{Region: sarifRegion{
+		StartLine:   start.Line,
+		StartColumn: col,
+	}}}
+	if start.Filename != "" {
+		loc.PhysicalLocation.ArtifactLocation = &sarifArtifact{start.Filename}
+	}
+	if r, n := utf8.DecodeRuneInString(e.src.text[start.Offset:]); n > 0 && r != '\n' {
+		loc.PhysicalLocation.Region.EndLine = start.Line
+		loc.PhysicalLocation.Region.EndColumn = col + 1
+	}
+	res.Locations = []sarifLocation{loc}
+	return res
+}
@@ -0,0 +1,99 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSARIF(t *testing.T) {
+	var state StateFn
+	state = func(l *Lexer) StateFn {
+		switch c, n := l.Advance(); {
+		case n == 0:
+			return nil
+		case c == '?':
+			l.Backup()
+			l.Warnf("question")
+			l.Advance()
+			l.Ignore()
+		case c != 'x' && c != '\u4e16' && c != '\n':
+			l.Backup()
+			l.Errorf("unexpected %q", c)
+			l.Advance()
+			l.Ignore()
+		default:
+			l.Ignore()
+		}
+		return state
+	}
+	l := New(state, "x!\n\u4e16?", WithFilename("a.txt"), WithDisplayColumns(), WithErrorCollection())
+	var warnings []error
+	for item := l.Next(); item.Type != ItemEOF; item = l.Next() {
+		if err := item.Err(); err != nil {
+			warnings = append(warnings, err)
+		}
+	}
+	errs := append(l.Errors(), warnings...)
+	errs = append(errs, errors.New("lexer crashed"))
+	s := &SARIF{Name: "calc", Version: "1.0"}
+	var b strings.Builder
+	if err := s.Write(&b, errs); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string
+					Rules []struct{ ID string }
+				}
+			}
+			Results []struct {
+				RuleID    string
+				RuleIndex *int
+				Level     string
+				Message   struct{ Text string }
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ URI string }
+						Region           struct{ StartLine, StartColumn, EndColumn int }
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(b.String()), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("log %s", b.String())
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "calc" || len(run.Tool.Driver.Rules) != 2 || run.Tool.Driver.Rules[1].ID != "Warning" {
+		t.Errorf("driver %+v", run.Tool.Driver)
+	}
+	if len(run.Results) != 3 {
+		t.Fatalf("results %s", b.String())
+	}
+	r := run.Results[0]
+	region := r.Locations[0].PhysicalLocation.Region
+	if r.RuleID != "Error" || *r.RuleIndex != 0 || r.Level != "error" || r.Message.Text != "unexpected '!'" ||
+		r.Locations[0].PhysicalLocation.ArtifactLocation.URI != "a.txt" || region.StartLine != 1 || region.StartColumn != 2 || region.EndColumn != 3 {
+		t.Errorf("result %+v", r)
+	}
+	r = run.Results[1]
+	region = r.Locations[0].PhysicalLocation.Region
+	if r.Level != "warning" || *r.RuleIndex != 1 || region.StartLine != 2 || region.StartColumn != 2 {
+		t.Errorf("result %+v", r)
+	}
+	if r = run.Results[2]; r.Message.Text != "lexer crashed" || r.RuleIndex != nil || len(r.Locations) != 0 {
+		t.Errorf("result %+v", r)
+	}
+}
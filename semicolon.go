@@ -0,0 +1,67 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"strings"
+)
+
+// A SemicolonRule describes automatic semicolon insertion in the style of Go.
+// When a newline separates an item whose type is in After from the next item,
+// a synthetic item of type Semicolon is inserted at the newline.
+type SemicolonRule struct {
+	Semicolon ItemType   // type of inserted items
+	Value     string     // value of inserted items, such as "\n"
+	After     []ItemType // types of items that may end a statement
+	AtEOF     bool       // also insert at the end of the input
+}
+
+// semicolons holds the state of automatic semicolon insertion.
+type semicolons struct {
+	rule  SemicolonRule
+	after map[ItemType]bool
+	prev  *Item // last item, other than errors, enqueued
+}
+
+// before inserts a semicolon ahead of i if needed.
+func (s *semicolons) before(l *Lexer, i *Item) {
+	if i.Type == ItemError || i.Type == ItemWarning {
+		return
+	}
+	prev := s.prev
+	s.prev = i
+	end := i.Pos
+	if l.newlines && i.Type == l.newline {
+		end = i.End()
+	}
+	if prev == nil || !s.after[prev.Type] || prev.src != i.src || end < prev.End() {
+		return
+	}
+	if n := strings.IndexAny(i.src.text[prev.End():end], "\r\n"); n >= 0 {
+		l.push(s.semicolon(i.src, prev.End()+n))
+	}
+}
+
+// atEOF inserts a semicolon at the end of the input if needed and returns
+// true if one was inserted.
+func (s *semicolons) atEOF(l *Lexer) bool {
+	if !s.rule.AtEOF || s.prev == nil || !s.after[s.prev.Type] {
+		return false
+	}
+	s.prev = s.semicolon(l.src, l.pos)
+	l.push(s.prev)
+	return true
+}
+
+func (s *semicolons) semicolon(src *Source, pos int) *Item {
+	return &Item{
+		Type:      s.rule.Semicolon,
+		Pos:       pos,
+		Value:     s.rule.Value,
+		src:       src,
+		end:       pos,
+		synthetic: true,
+	}
+}
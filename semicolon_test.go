@@ -0,0 +1,74 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+const (
+	semiIdent ItemType = iota
+	semiOp
+	semiSemicolon
+)
+
+func lexSemiTokens(l *Lexer) StateFn {
+	l.IgnoreRun(" \t\r\n")
+	switch c, _ := l.Peek(); {
+	case c == EOF:
+		return nil
+	case c == ';':
+		l.Advance()
+		l.Emit(semiSemicolon)
+	case c >= 'a' && c <= 'z':
+		l.AcceptRun("abcdefghijklmnopqrstuvwxyz")
+		l.Emit(semiIdent)
+	default:
+		l.Advance()
+		l.Emit(semiOp)
+	}
+	return lexSemiTokens
+}
+
+func TestSemicolons(t *testing.T) {
+	rule := SemicolonRule{Semicolon: semiSemicolon, Value: "\n", After: []ItemType{semiIdent}, AtEOF: true}
+	items := Collect(New(lexSemiTokens, "a +\n b\n\nc; d\n+ e", WithSemicolons(rule)))
+	want := []struct {
+		typ       ItemType
+		value     string
+		pos       int
+		synthetic bool
+	}{
+		{semiIdent, "a", 0, false},
+		{semiOp, "+", 2, false},
+		{semiIdent, "b", 5, false},
+		{semiSemicolon, "\n", 6, true},
+		{semiIdent, "c", 8, false},
+		{semiSemicolon, ";", 9, false},
+		{semiIdent, "d", 11, false},
+		{semiSemicolon, "\n", 12, true},
+		{semiOp, "+", 13, false},
+		{semiIdent, "e", 15, false},
+		{semiSemicolon, "\n", 16, true},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d: %v", len(items), len(want), items)
+	}
+	for i, w := range want {
+		it := items[i]
+		if it.Type != w.typ || it.Value != w.value || it.Pos != w.pos || it.Synthetic() != w.synthetic {
+			t.Errorf("item %d: %v %q at %d synthetic %v, want %v %q at %d synthetic %v",
+				i, it.Type, it.Value, it.Pos, it.Synthetic(), w.typ, w.value, w.pos, w.synthetic)
+		}
+	}
+}
+
+func TestSemicolonsNotAtEOF(t *testing.T) {
+	rule := SemicolonRule{Semicolon: semiSemicolon, After: []ItemType{semiIdent}}
+	items := Collect(New(lexSemiTokens, "a\n", WithSemicolons(rule)))
+	if len(items) != 1 {
+		t.Errorf("got items %v", items)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A RuneSet is a set of runes compiled into a structure with fast membership
+// tests.  Membership of ASCII runes is a bit test, other runes are found by
+// binary search over sorted ranges.  A RuneSet is immutable once constructed
+// and is safe for concurrent use.
+type RuneSet struct {
+	ascii  [2]uint64
+	ranges []runeRange // sorted, disjoint, and non-adjacent
+}
+
+type runeRange struct {
+	lo, hi rune // inclusive
+}
+
+// NewRuneSet returns the union of sets.  Each element of sets must be a
+// string (every rune in the string is a member), a rune, a
+// *unicode.RangeTable, or a *RuneSet.  NewRuneSet panics if given any other
+// type.
+//
+//	ident := NewRuneSet(unicode.Letter, unicode.Digit, "_-")
+func NewRuneSet(sets ...interface{}) *RuneSet {
+	var rs []runeRange
+	for _, set := range sets {
+		switch set := set.(type) {
+		case string:
+			for _, r := range set {
+				rs = append(rs, runeRange{r, r})
+			}
+		case rune:
+			rs = append(rs, runeRange{set, set})
+		case *unicode.RangeTable:
+			rs = appendTable(rs, set)
+		case *RuneSet:
+			rs = append(rs, set.ranges...)
+		default:
+			panic(fmt.Sprintf("lexer: invalid rune set type %T", set))
+		}
+	}
+	return newRuneSet(rs)
+}
+
+func appendTable(rs []runeRange, tab *unicode.RangeTable) []runeRange {
+	for _, r := range tab.R16 {
+		rs = appendStride(rs, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	for _, r := range tab.R32 {
+		rs = appendStride(rs, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	return rs
+}
+
+func appendStride(rs []runeRange, lo, hi, stride rune) []runeRange {
+	if stride == 1 {
+		return append(rs, runeRange{lo, hi})
+	}
+	for r := lo; r <= hi; r += stride {
+		rs = append(rs, runeRange{r, r})
+	}
+	return rs
+}
+
+// newRuneSet normalizes rs, which may be modified, and returns a set
+// containing its runes.
+func newRuneSet(rs []runeRange) *RuneSet {
+	sort.Slice(rs, func(i, j int) bool { return rs[i].lo < rs[j].lo })
+	s := new(RuneSet)
+	for _, r := range rs {
+		if r.lo > r.hi {
+			continue
+		}
+		n := len(s.ranges)
+		if n > 0 && r.lo <= s.ranges[n-1].hi+1 {
+			if r.hi > s.ranges[n-1].hi {
+				s.ranges[n-1].hi = r.hi
+			}
+			continue
+		}
+		s.ranges = append(s.ranges, r)
+	}
+	for _, r := range s.ranges {
+		for c := r.lo; c <= r.hi && c < utf8.RuneSelf; c++ {
+			s.ascii[c>>6] |= 1 << uint(c&63)
+		}
+	}
+	return s
+}
+
+// RuneRange returns the set of runes from lo to hi, inclusive.
+func RuneRange(lo, hi rune) *RuneSet {
+	return newRuneSet([]runeRange{{lo, hi}})
+}
+
+// Union returns a set containing the runes in s and the runes in each of sets.
+// The elements of sets may be of any type accepted by NewRuneSet.
+func (s *RuneSet) Union(sets ...interface{}) *RuneSet {
+	return NewRuneSet(append([]interface{}{s}, sets...)...)
+}
+
+// Intersect returns the set of runes in both s and t.
+func (s *RuneSet) Intersect(t *RuneSet) *RuneSet {
+	var rs []runeRange
+	for i, j := 0, 0; i < len(s.ranges) && j < len(t.ranges); {
+		a, b := s.ranges[i], t.ranges[j]
+		lo, hi := a.lo, a.hi
+		if b.lo > lo {
+			lo = b.lo
+		}
+		if b.hi < hi {
+			hi = b.hi
+		}
+		if lo <= hi {
+			rs = append(rs, runeRange{lo, hi})
+		}
+		if a.hi < b.hi {
+			i++
+		} else {
+			j++
+		}
+	}
+	return newRuneSet(rs)
+}
+
+// Minus returns the set of runes in s that are not in t.
+func (s *RuneSet) Minus(t *RuneSet) *RuneSet {
+	return s.Intersect(t.complement())
+}
+
+// complement returns the set of valid runes not in s.
+func (s *RuneSet) complement() *RuneSet {
+	var rs []runeRange
+	next := rune(0)
+	for _, r := range s.ranges {
+		if r.lo > next {
+			rs = append(rs, runeRange{next, r.lo - 1})
+		}
+		next = r.hi + 1
+	}
+	if next <= unicode.MaxRune {
+		rs = append(rs, runeRange{next, unicode.MaxRune})
+	}
+	return newRuneSet(rs)
+}
+
+// Contains returns true if r is a member of s.
+func (s *RuneSet) Contains(r rune) bool {
+	if r >= 0 && r < utf8.RuneSelf {
+		return s.ascii[r>>6]&(1<<uint(r&63)) != 0
+	}
+	i := sort.Search(len(s.ranges), func(i int) bool { return s.ranges[i].hi >= r })
+	return i < len(s.ranges) && s.ranges[i].lo <= r
+}
+
+// AcceptSet advances the lexer if the next rune is in s.
+func (l *Lexer) AcceptSet(s *RuneSet) bool {
+	if l.AcceptFunc(s.Contains) {
+		return true
+	}
+	if l.expect != nil {
+		l.expectedSet(s)
+	}
+	return false
+}
+
+// AcceptRunSet advances l's position as long as the current rune is in s.
+func (l *Lexer) AcceptRunSet(s *RuneSet) (n int) {
+	if !l.slow {
+		// Consume the leading ASCII runes of the run by table lookup.
+		pos, input := l.pos, l.input
+		var last byte
+		for uint(pos) < uint(len(input)) {
+			c := input[pos]
+			if c >= utf8.RuneSelf || s.ascii[c>>6]&(1<<(c&63)) == 0 {
+				break
+			}
+			last = c
+			pos++
+		}
+		if n = pos - l.pos; n > 0 {
+			l.last, l.width, l.pos = rune(last), 1, pos
+		}
+	}
+	n += l.AcceptRunFunc(s.Contains)
+	if l.expect != nil {
+		l.expectedSet(s)
+	}
+	return n
+}
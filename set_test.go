@@ -0,0 +1,60 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+	"unicode"
+)
+
+func TestRuneSet(t *testing.T) {
+	set := NewRuneSet(unicode.Letter, unicode.Digit, "_-", '$')
+	for _, r := range "azAZ09_-$éλ٣" {
+		if !set.Contains(r) {
+			t.Errorf("%q not in set", r)
+		}
+	}
+	for _, r := range " .+\n " {
+		if set.Contains(r) {
+			t.Errorf("%q in set", r)
+		}
+	}
+
+	l := New(func(*Lexer) StateFn { return nil }, "ab_c-λ1 d")
+	if !l.AcceptSet(set) {
+		t.Errorf("AcceptSet failed")
+	}
+	if n := l.AcceptRunSet(set); n != 6 || l.Current() != "ab_c-λ1" {
+		t.Errorf("AcceptRunSet: got %d %q", n, l.Current())
+	}
+}
+
+func TestRuneSetAlgebra(t *testing.T) {
+	letters := NewRuneSet(unicode.Letter)
+	vowels := NewRuneSet("aeiouAEIOU")
+	consonants := RuneRange('a', 'z').Union(RuneRange('A', 'Z')).Minus(vowels)
+	for _, test := range []struct {
+		name string
+		set  *RuneSet
+		in   string
+		out  string
+	}{
+		{"consonants", consonants, "bcxyzBZ", "aeAE1é"},
+		{"ascii letters", letters.Intersect(RuneRange(0, 0x7f)), "azAZ", "é_1"},
+		{"union", vowels.Union(unicode.Digit, "_"), "aU0٣_", "bé"},
+		{"minus everything", vowels.Minus(letters), "", "aeiou"},
+	} {
+		for _, r := range test.in {
+			if !test.set.Contains(r) {
+				t.Errorf("%s: %q not in set", test.name, r)
+			}
+		}
+		for _, r := range test.out {
+			if test.set.Contains(r) {
+				t.Errorf("%s: %q in set", test.name, r)
+			}
+		}
+	}
+}
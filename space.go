@@ -0,0 +1,83 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"unicode"
+)
+
+// UnicodeSpace is the whitespace of a Lexer unless WithWhitespace is given,
+// the runes for which unicode.IsSpace returns true.
+var UnicodeSpace = NewRuneSet(unicode.White_Space)
+
+// CharClasses are the character classes of a Lexer, given with WithClasses.
+type CharClasses struct {
+	Space  *RuneSet // skipped by SkipSpace
+	Digit  *RuneSet // decimal digits
+	Letter *RuneSet // letters that may begin an identifier
+}
+
+// UnicodeClasses are the default character classes, matching unicode.IsSpace,
+// unicode.IsDigit and unicode.IsLetter.
+var UnicodeClasses = CharClasses{
+	Space:  UnicodeSpace,
+	Digit:  NewRuneSet(unicode.Digit),
+	Letter: NewRuneSet(unicode.Letter),
+}
+
+// ASCIIClasses are character classes containing only ASCII runes: the space
+// characters " \t\n\v\f\r", the digits 0-9, and the letters a-z and A-Z.
+// Membership of every rune is a table lookup.
+var ASCIIClasses = CharClasses{
+	Space:  NewRuneSet(" \t\n\v\f\r"),
+	Digit:  RuneRange('0', '9'),
+	Letter: NewRuneSet(RuneRange('a', 'z'), RuneRange('A', 'Z')),
+}
+
+// Classes returns the character classes of l.
+func (l *Lexer) Classes() CharClasses {
+	c := l.classes
+	if c.Space == nil {
+		c.Space = UnicodeClasses.Space
+	}
+	if c.Digit == nil {
+		c.Digit = UnicodeClasses.Digit
+	}
+	if c.Letter == nil {
+		c.Letter = UnicodeClasses.Letter
+	}
+	return c
+}
+
+// Whitespace returns the set of runes that l treats as whitespace.
+func (l *Lexer) Whitespace() *RuneSet {
+	if l.classes.Space == nil {
+		return UnicodeSpace
+	}
+	return l.classes.Space
+}
+
+// IsSpace returns true if r is whitespace for l.
+func (l *Lexer) IsSpace(r rune) bool {
+	return l.Whitespace().Contains(r)
+}
+
+// IsDigit returns true if r is in l's class of digits.
+func (l *Lexer) IsDigit(r rune) bool {
+	return l.Classes().Digit.Contains(r)
+}
+
+// IsLetter returns true if r is in l's class of letters.
+func (l *Lexer) IsLetter(r rune) bool {
+	return l.Classes().Letter.Contains(r)
+}
+
+// SkipSpace advances l's position past a run of whitespace and throws away
+// the current lexeme.  SkipSpace returns the number of runes skipped.
+func (l *Lexer) SkipSpace() int {
+	n := l.AcceptRunSet(l.Whitespace())
+	l.Ignore()
+	return n
+}
@@ -0,0 +1,106 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestUnicodeSpace(t *testing.T) {
+	for r := rune(0); r < 0x3100; r++ {
+		if UnicodeSpace.Contains(r) != unicode.IsSpace(r) {
+			t.Errorf("UnicodeSpace.Contains(%U) = %v", r, !unicode.IsSpace(r))
+		}
+	}
+}
+
+func TestLexerSkipSpace(t *testing.T) {
+	l := New(lexSparse, " \t\u00a0x")
+	if n := l.SkipSpace(); n != 3 || l.Pos() != 4 || l.Current() != "" {
+		t.Errorf("skipped %d runes to %d", n, l.Pos())
+	}
+
+	l = New(lexSparse, " \t\n x", WithWhitespace(NewRuneSet(" \t")))
+	if n := l.SkipSpace(); n != 2 || l.IsSpace('\n') || !l.IsSpace(' ') {
+		t.Errorf("skipped %d runes", n)
+	}
+	l.Accept("\n")
+	l.Ignore()
+	if n := l.SkipSpace(); n != 1 || l.Pos() != 4 {
+		t.Errorf("skipped %d runes to %d", n, l.Pos())
+	}
+}
+
+func TestLexerClasses(t *testing.T) {
+	uni := New(lexSparse, "")
+	ascii := New(lexSparse, "", WithClasses(ASCIIClasses))
+	for _, test := range []struct {
+		r            rune
+		space, digit bool
+		letter       bool
+		ascii        bool
+	}{
+		{' ', true, false, false, true},
+		{'\u00a0', true, false, false, false},
+		{'7', false, true, false, true},
+		{'\u0663', false, true, false, false},
+		{'q', false, false, true, true},
+		{'é', false, false, true, false},
+		{'_', false, false, false, true},
+	} {
+		for _, l := range []*Lexer{uni, ascii} {
+			match := l == uni || test.ascii
+			got := [3]bool{l.IsSpace(test.r), l.IsDigit(test.r), l.IsLetter(test.r)}
+			want := [3]bool{test.space && match, test.digit && match, test.letter && match}
+			if got != want {
+				t.Errorf("%q (ascii %v): classes %v want %v", test.r, l == ascii, got, want)
+			}
+		}
+	}
+
+	l := New(lexSparse, "", WithWhitespace(NewRuneSet(" ")), WithClasses(CharClasses{Digit: RuneRange('0', '7')}))
+	if c := l.Classes(); c.Letter != UnicodeClasses.Letter || c.Space.Contains('\t') || c.Digit.Contains('8') {
+		t.Errorf("classes not merged")
+	}
+}
+
+func TestLexerAcceptRunSetFast(t *testing.T) {
+	// WithInvariants disables the fast path of AcceptRunSet.
+	set := NewRuneSet("abcé")
+	for _, input := range []string{"", "abc", "abc+", "abé", "ab\xffc", "+ab"} {
+		fast := New(lexSparse, input)
+		slow := New(lexSparse, input, WithInvariants())
+		for _, l := range []*Lexer{fast, slow} {
+			l.AcceptRunSet(set)
+			l.Accept("+")
+		}
+		fr, fw := fast.Last()
+		sr, sw := slow.Last()
+		if fast.Current() != slow.Current() || fr != sr || fw != sw {
+			t.Errorf("%q: fast %q %q %d; slow %q %q %d", input,
+				fast.Current(), fr, fw, slow.Current(), sr, sw)
+		}
+	}
+}
+
+func BenchmarkSkipSpaceUnicode(b *testing.B) {
+	benchmarkSkipSpace(b)
+}
+
+func BenchmarkSkipSpaceASCII(b *testing.B) {
+	benchmarkSkipSpace(b, WithClasses(ASCIIClasses))
+}
+
+func benchmarkSkipSpace(b *testing.B, opts ...Option) {
+	input := strings.Repeat(" \t\n", 1000) + "x"
+	l := New(lexSparse, input, opts...)
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		l.SetPos(0)
+		l.SkipSpace()
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+// State is a saved copy of a Lexer's scanning state.  See Save.
+type State struct {
+	mark    scanMark
+	start   int
+	state   StateFn
+	stack   []StateFn
+	input   string
+	src     *Source
+	inputs  []savedInput
+	modes   []string
+	semi    *Item // previous item for semicolon insertion
+	layout  layoutState
+	queued  []*Item // items queued but not consumed at the time of Save
+	index   int     // absolute index of queued[0]
+	nerrs   int
+	hist    []*Item
+	nhist   int
+	halt    bool
+	bidi    int // offset of the next bidi control to report
+	invalid int // offset of the next invalid byte to report
+}
+
+// Save returns the current state of l so that it may be restored after a
+// failed alternative with Restore.
+//
+// The saved state includes the start and current positions, the last rune
+// read, the current input and inputs suspended by PushInput, the current
+// StateFn, state stack and modes, items that are queued but not yet consumed
+// by Next, errors recorded by WithErrorCollection, problems pending from
+// WithBidiWarnings and InvalidError, and the history used by LastEmitted.  User data and items consumed by Next are not saved.
+func (l *Lexer) Save() State {
+	return State{
+		mark:    l.mark(),
+		start:   l.start,
+		state:   l.state,
+		stack:   append([]StateFn(nil), l.stack...),
+		input:   l.input,
+		src:     l.src,
+		inputs:  append([]savedInput(nil), l.inputs...),
+		modes:   append([]string(nil), l.modeStack...),
+		semi:    l.semiPrev(),
+		layout:  l.layoutState(),
+		queued:  append([]*Item(nil), l.items[l.head:]...),
+		index:   l.base + l.head,
+		nerrs:   len(l.errs),
+		hist:    append([]*Item(nil), l.hist...),
+		nhist:   l.nhist,
+		halt:    l.halt,
+		bidi:    l.nextBidi,
+		invalid: l.nextInvalid,
+	}
+}
+
+// Restore returns l to the state s.  Items emitted since s was saved are
+// discarded.  Items that were queued when s was saved are queued again,
+// except for those consumed by Next in the meantime because they have already
+// been delivered.
+func (l *Lexer) Restore(s State) {
+	n := len(l.items)
+	l.reset(s.mark)
+	l.start = s.start
+	l.state = s.state
+	l.stack = append(l.stack[:0], s.stack...)
+	l.input, l.src = s.input, s.src
+	l.inputs = append(l.inputs[:0], s.inputs...)
+	l.modeStack = append(l.modeStack[:0], s.modes...)
+	if l.semi != nil {
+		l.semi.prev = s.semi
+	}
+	if l.layout != nil {
+		l.layout.restore(s.layout)
+	}
+	l.items = l.items[:l.head]
+	if consumed := l.base + l.head - s.index; consumed < len(s.queued) {
+		l.items = append(l.items, s.queued[consumed:]...)
+	}
+	if len(l.errs) > s.nerrs {
+		l.errs = l.errs[:s.nerrs]
+	}
+	copy(l.hist, s.hist)
+	l.nhist = s.nhist
+	l.halt = s.halt
+	l.nextBidi, l.nextInvalid = s.bidi, s.invalid
+	if l.rec != nil {
+		l.rec.record(Op{Kind: OpRestore, Pos: l.pos, Start: l.start, N: n - len(l.items)})
+	}
+}
+
+func (l *Lexer) semiPrev() *Item {
+	if l.semi == nil {
+		return nil
+	}
+	return l.semi.prev
+}
+
+func (l *Lexer) layoutState() layoutState {
+	if l.layout == nil {
+		return layoutState{}
+	}
+	return l.layout.save()
+}
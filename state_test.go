@@ -0,0 +1,77 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+func TestLexerSaveRestore(t *testing.T) {
+	const (
+		itemWord ItemType = iota
+		itemNumber
+	)
+	start := func(l *Lexer) StateFn {
+		l.AcceptRun("ab")
+		l.Emit(itemWord)
+		s := l.Save()
+		// speculatively lex a word followed by digits
+		l.AcceptRun("ab")
+		l.Emit(itemWord)
+		l.Errorf("oops")
+		if l.AcceptRun("0123456789") == 0 {
+			l.Restore(s)
+		}
+		l.AcceptRun("abc")
+		l.Emit(itemNumber)
+		return nil
+	}
+	l := New(start, "abbac", WithErrorCollection())
+	var values []string
+	for item := l.Next(); item.Type != ItemEOF; item = l.Next() {
+		values = append(values, item.Value)
+	}
+	if len(values) != 2 || values[0] != "abba" || values[1] != "c" {
+		t.Errorf("unexpected items %q", values)
+	}
+	if len(l.Errors()) != 0 {
+		t.Errorf("errors not restored: %v", l.Errors())
+	}
+	if item := l.LastEmitted(0); item == nil || item.Value != "c" {
+		t.Errorf("unexpected last item %v", item)
+	}
+}
+
+func TestSaveRestoreBidi(t *testing.T) {
+	start := func(l *Lexer) StateFn {
+		s := l.Save()
+		l.AcceptRun("ab\u202e")
+		l.Restore(s)
+		l.AcceptRun("ab\u202e")
+		l.Emit(1)
+		return nil
+	}
+	items := Collect(New(start, "a\u202eb", WithBidiWarnings()))
+	if len(items) != 2 || items[0].Type != ItemWarning || items[0].Pos != 1 {
+		t.Errorf("unexpected items %v", items)
+	}
+}
+
+func TestSaveRestoreInvalid(t *testing.T) {
+	start := func(l *Lexer) StateFn {
+		s := l.Save()
+		l.Advance()
+		l.Advance()
+		l.Restore(s)
+		l.Advance()
+		l.Advance()
+		l.Emit(1)
+		return nil
+	}
+	items := Collect(New(start, "a\xff", WithInvalidUTF8(InvalidError)))
+	if len(items) != 2 || items[0].Type != ItemError || items[0].Pos != 1 {
+		t.Errorf("unexpected items %v", items)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import "unicode/utf8"
+
+// A Transition describes one step of a Lexer taken with Step.
+type Transition struct {
+	State string  // name of the state function run, "nil" at the end of input
+	Next  string  // name of the state function it returned
+	From  int     // position before the step
+	To    int     // position after the step
+	Runes int     // number of runes between From and To
+	Items []*Item // items queued during the step
+}
+
+// Step runs exactly one state function of l, or once l has stopped the
+// insertion of items due at the end of its input, and describes the
+// transition.  Step returns false if l has stopped and has nothing more to
+// insert.  Queued items are not consumed and are returned by later calls to
+// Next, so Step is suited to debuggers and visualizations of a lexer.
+//
+// The Runes of a transition are counted only when the step did not move
+// backward or change the input with PushInput or PopInput; otherwise Runes
+// is -1.
+//
+// Step is safe for concurrent use if l was created with the WithSync option.
+func (l *Lexer) Step() (Transition, bool) {
+	if l.mu != nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	t := Transition{State: stateName(l.state), From: l.pos}
+	input, n := l.input, len(l.items)
+	if l.state == nil {
+		if !l.atEOF() {
+			l.finish()
+			return t, false
+		}
+	} else {
+		l.runState()
+	}
+	t.Next = stateName(l.state)
+	t.To = l.pos
+	t.Runes = -1
+	if l.input == input && t.To >= t.From {
+		t.Runes = utf8.RuneCountInString(input[t.From:t.To])
+	}
+	if len(l.items) > n {
+		t.Items = append([]*Item(nil), l.items[n:]...)
+	}
+	return t, true
+}
@@ -0,0 +1,52 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStep(t *testing.T) {
+	l := New(lexSparse, "éx", WithSemicolons(SemicolonRule{Semicolon: 9, After: []ItemType{0}, AtEOF: true}))
+	var steps []Transition
+	for {
+		tr, ok := l.Step()
+		if !ok {
+			break
+		}
+		steps = append(steps, tr)
+	}
+	if len(steps) != 4 {
+		t.Fatalf("%d steps: %+v", len(steps), steps)
+	}
+	for i, tr := range steps[:3] {
+		if !strings.HasSuffix(tr.State, ".lexSparse") {
+			t.Errorf("step %d: state %q", i, tr.State)
+		}
+	}
+	if tr := steps[0]; tr.From != 0 || tr.To != 2 || tr.Runes != 1 || len(tr.Items) != 0 {
+		t.Errorf("step 0: %+v", tr)
+	}
+	if tr := steps[1]; tr.Runes != 1 || len(tr.Items) != 1 || tr.Items[0].Value != "x" {
+		t.Errorf("step 1: %+v", tr)
+	}
+	if tr := steps[2]; tr.Runes != 0 || tr.Next != "nil" {
+		t.Errorf("step 2: %+v", tr)
+	}
+	if tr := steps[3]; tr.State != "nil" || len(tr.Items) != 1 || tr.Items[0].Type != 9 {
+		t.Errorf("step 3: %+v", tr)
+	}
+	// Items queued by Step are delivered by Next.
+	if item := l.Next(); item.Value != "x" {
+		t.Errorf("unexpected item %v", item)
+	}
+	if item := l.Next(); item.Type != 9 {
+		t.Errorf("unexpected item %v", item)
+	}
+	if item := l.Next(); item.Type != ItemEOF {
+		t.Errorf("unexpected item %v", item)
+	}
+}
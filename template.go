@@ -0,0 +1,77 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"strings"
+)
+
+// Template lexes input consisting of raw text interrupted by actions enclosed
+// in delimiters, as in text/template.  Raw text is emitted as items of type
+// Text and the delimiters as LeftDelim and RightDelim items.  After a left
+// delimiter control transfers to the Action state, whose states must call
+// CloseAction before scanning each token.
+//
+//	tmpl := &lexer.Template{Left: "{{", Right: "}}", Text: itemText, ...}
+//	tmpl.Action = func(l *lexer.Lexer) lexer.StateFn {
+//		if next, ok := tmpl.CloseAction(l); ok {
+//			return next
+//		}
+//		// scan one token of the action
+//	}
+//	lex := lexer.New(tmpl.Start(), input)
+//
+// A Template holds no lexer state and may be shared.
+type Template struct {
+	Left, Right string // action delimiters
+	Text        ItemType
+	LeftDelim   ItemType
+	RightDelim  ItemType
+	Action      StateFn // state entered after a left delimiter
+}
+
+// Start returns the state that lexes raw text.
+func (t *Template) Start() StateFn {
+	return t.lexText
+}
+
+func (t *Template) lexText(l *Lexer) StateFn {
+	i := strings.Index(l.input[l.pos:], t.Left)
+	if i < 0 {
+		l.skip(len(l.input) - l.pos)
+	} else {
+		l.skip(i)
+	}
+	if l.pos > l.start {
+		l.Emit(t.Text)
+	}
+	if i < 0 {
+		return nil
+	}
+	l.AcceptString(t.Left)
+	l.Emit(t.LeftDelim)
+	return t.Action
+}
+
+// CloseAction checks for the end of an action at l's position.  If the right
+// delimiter follows it is emitted and CloseAction returns the raw text state.
+// If the input is consumed an error is emitted at the unclosed left delimiter
+// and CloseAction returns a nil state.  In either case CloseAction returns
+// true, and the caller should return the state.  Otherwise CloseAction returns
+// false and the action continues.
+func (t *Template) CloseAction(l *Lexer) (StateFn, bool) {
+	if l.AcceptString(t.Right) {
+		l.Emit(t.RightDelim)
+		return t.lexText, true
+	}
+	if l.pos < len(l.input) {
+		return nil, false
+	}
+	pos := strings.LastIndex(l.input[:l.start], t.Left)
+	if pos < 0 {
+		pos = l.start
+	}
+	return l.fail(pos, "unclosed action", ErrUnexpectedEOF), true
+}
@@ -0,0 +1,58 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+const (
+	tmplText ItemType = iota
+	tmplLeft
+	tmplRight
+	tmplIdent
+)
+
+func newTestTemplate() *Template {
+	tmpl := &Template{Left: "{{", Right: "}}", Text: tmplText, LeftDelim: tmplLeft, RightDelim: tmplRight}
+	tmpl.Action = func(l *Lexer) StateFn {
+		l.IgnoreRun(" ")
+		if next, ok := tmpl.CloseAction(l); ok {
+			return next
+		}
+		if l.AcceptRun("abcdefghijklmnopqrstuvwxyz.") == 0 {
+			c, _ := l.Advance()
+			return l.Errorf("unexpected %q in action", c)
+		}
+		l.Emit(tmplIdent)
+		return tmpl.Action
+	}
+	return tmpl
+}
+
+func TestTemplate(t *testing.T) {
+	l := New(newTestTemplate().Start(), "Hi {{ .name }}!{{x}}")
+	var got []string
+	for _, item := range Collect(l) {
+		got = append(got, item.Value)
+	}
+	want := []string{"Hi ", "{{", ".name", "}}", "!", "{{", "x", "}}"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q want %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("item %d: got %q want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTemplateUnclosed(t *testing.T) {
+	items := Collect(New(newTestTemplate().Start(), "a {{ b "))
+	last := items[len(items)-1]
+	if last.Type != ItemError || last.Pos != 2 {
+		t.Errorf("unexpected item %v at %d", last, last.Pos)
+	}
+}
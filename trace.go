@@ -0,0 +1,91 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import "time"
+
+// A Tracer is notified as a lexer created with WithTracer begins and ends
+// lexing its input and each state function it runs, so that spans of a
+// tracing system or a profiler can be wired to a lexer without this package
+// depending on them.  Begin and end calls for a lexer are properly nested and
+// made by the goroutine calling Next, and a Tracer shared by several lexers
+// may tell them apart by the *Lexer argument.
+type Tracer interface {
+	BeginDocument(l *Lexer, t DocumentTrace)
+	EndDocument(l *Lexer, t DocumentTrace)
+	BeginState(l *Lexer, t StateTrace)
+	EndState(l *Lexer, t StateTrace)
+}
+
+// A DocumentTrace describes the lexing of one input.  A document begins when
+// its first state function runs and ends when Next or NextBudget first
+// returns ItemEOF, when Step first returns false, or when the lexer is Reset
+// before then.  Duration, Items and Errors are zero when the document begins.
+type DocumentTrace struct {
+	Name     string        // filename of the input, if any
+	Size     int           // length of the input in bytes
+	Start    time.Time     // time the document began
+	Duration time.Duration // time from Start until the document ended
+	Items    int           // number of items queued
+	Errors   int           // number of errors, including collected errors
+}
+
+// A StateTrace describes one call of a state function.  To, Duration and
+// Items are zero when the state begins.
+type StateTrace struct {
+	State    string        // name of the state function, as in OpState operations
+	From     int           // position before the call
+	To       int           // position after the call
+	Start    time.Time     // time the call began
+	Duration time.Duration // length of the call
+	Items    int           // number of items queued by the call
+}
+
+// tracing is the state of a lexer's Tracer.
+type tracing struct {
+	t      Tracer
+	doc    DocumentTrace
+	began  bool
+	ended  bool
+	nitems int // length of the item buffer when the current state began
+}
+
+// beginState notifies the Tracer that l is running its current state,
+// beginning the document first if necessary.
+func (tr *tracing) beginState(l *Lexer) StateTrace {
+	now := time.Now()
+	if !tr.began {
+		tr.began = true
+		tr.doc.Name = l.src.name
+		tr.doc.Start = now
+		tr.t.BeginDocument(l, tr.doc)
+	}
+	st := StateTrace{State: stateName(l.state), From: l.pos, Start: now}
+	tr.nitems = len(l.items)
+	tr.t.BeginState(l, st)
+	return st
+}
+
+// endState notifies the Tracer that the state st has returned.
+func (tr *tracing) endState(l *Lexer, st StateTrace) {
+	st.To = l.pos
+	st.Duration = time.Since(st.Start)
+	if n := len(l.items) - tr.nitems; n > 0 {
+		st.Items = n
+		tr.doc.Items += n
+	}
+	tr.t.EndState(l, st)
+}
+
+// endDocument notifies the Tracer once that the document has ended, if it
+// began.
+func (tr *tracing) endDocument(l *Lexer) {
+	if !tr.began || tr.ended {
+		return
+	}
+	tr.ended = true
+	tr.doc.Duration = time.Since(tr.doc.Start)
+	tr.t.EndDocument(l, tr.doc)
+}
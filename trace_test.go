@@ -0,0 +1,60 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type logTracer []string
+
+func (t *logTracer) BeginDocument(l *Lexer, d DocumentTrace) {
+	*t = append(*t, fmt.Sprintf("begin %s %d", d.Name, d.Size))
+}
+
+func (t *logTracer) EndDocument(l *Lexer, d DocumentTrace) {
+	*t = append(*t, fmt.Sprintf("end %d items %d errors", d.Items, d.Errors))
+}
+
+func (t *logTracer) BeginState(l *Lexer, s StateTrace) {
+	*t = append(*t, fmt.Sprintf("state %s @%d", s.State[strings.LastIndex(s.State, ".")+1:], s.From))
+}
+
+func (t *logTracer) EndState(l *Lexer, s StateTrace) {
+	*t = append(*t, fmt.Sprintf("return @%d %d items", s.To, s.Items))
+}
+
+func TestTracer(t *testing.T) {
+	var log logTracer
+	l := New(lexRecorded, "ab ?", WithTracer(&log), WithFilename("x.txt"))
+	Collect(l)
+	l.Next()
+	want := []string{
+		"begin x.txt 4",
+		"state lexRecorded @0",
+		"return @2 1 items",
+		"state lexRecorded @2",
+		"return @4 1 items",
+		"end 2 items 1 errors",
+	}
+	if strings.Join(log, "\n") != strings.Join(want, "\n") {
+		t.Errorf("trace:\n%s\nwant:\n%s", strings.Join(log, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestTracerNextBudget(t *testing.T) {
+	var log logTracer
+	l := New(lexRecorded, "ab", WithTracer(&log))
+	for i, _ := l.NextBudget(Budget{States: 1}); i == nil || i.Type != ItemEOF; i, _ = l.NextBudget(Budget{States: 1}) {
+	}
+	l = New(lexRecorded, "ab", WithTracer(&log))
+	for _, ok := l.Step(); ok; _, ok = l.Step() {
+	}
+	if n := len(log); n != 12 || log[5] != "end 1 items 0 errors" || log[11] != log[5] {
+		t.Errorf("trace:\n%s", strings.Join(log, "\n"))
+	}
+}
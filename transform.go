@@ -0,0 +1,88 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// A Transform rewrites the input as it is read by Advance, without
+// materializing a modified copy of the input.  Given the input and a position
+// before the end of it, a Transform returns the rune to read at pos and the
+// number of input bytes it spans, or ok false if the input at pos is read
+// unchanged.  A Transform that consumes the remainder of the input without
+// producing a rune returns EOF with the number of bytes consumed.
+//
+// Positions reported by the lexer remain offsets in the original input.
+type Transform func(input string, pos int) (r rune, width int, ok bool)
+
+// SpliceLines is a Transform that deletes each backslash-newline sequence
+// from the input, joining physical lines as in the C preprocessor.  Both "\n"
+// and "\r\n" line endings are spliced.
+func SpliceLines(input string, pos int) (rune, int, bool) {
+	i := pos
+	for {
+		switch {
+		case strings.HasPrefix(input[i:], "\\\n"):
+			i += 2
+			continue
+		case strings.HasPrefix(input[i:], "\\\r\n"):
+			i += 3
+			continue
+		}
+		break
+	}
+	if i == pos {
+		return 0, 0, false
+	}
+	if i == len(input) {
+		return EOF, i - pos, true
+	}
+	r, n := utf8.DecodeRuneInString(input[i:])
+	return r, i - pos + n, true
+}
+
+// advanceTransform reads the next rune through l's transform.  ok is false if
+// the transform left the input at l's position unchanged.
+func (l *Lexer) advanceTransform() (r rune, width int, ok bool) {
+	r, width, ok = l.src.transform(l.input, l.pos)
+	if !ok {
+		return 0, 0, false
+	}
+	l.pos += width
+	if r == EOF && l.pos == len(l.input) {
+		l.width = 0
+		return EOF, 0, true
+	}
+	l.last, l.width = r, width
+	return r, width, true
+}
+
+// cook returns the transformed text of input[start:end].  If the transform
+// leaves the text unchanged cook does not allocate.
+func (l *Lexer) cook(start, end int) string {
+	var b strings.Builder
+	raw := start // start of input copied to b verbatim
+	for pos := start; pos < end; {
+		r, w, ok := l.src.transform(l.input[:end], pos)
+		if !ok {
+			_, n := utf8.DecodeRuneInString(l.input[pos:end])
+			pos += n
+			continue
+		}
+		b.WriteString(l.input[raw:pos])
+		pos += w
+		raw = pos
+		if r != EOF || pos < end {
+			b.WriteRune(r)
+		}
+	}
+	if raw == start {
+		return l.input[start:end]
+	}
+	b.WriteString(l.input[raw:end])
+	return b.String()
+}
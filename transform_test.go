@@ -0,0 +1,66 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+	"unicode"
+)
+
+func lexSplicedWords(l *Lexer) StateFn {
+	l.IgnoreWhile(unicode.IsSpace)
+	if l.AcceptRunFunc(func(r rune) bool { return r != EOF && !unicode.IsSpace(r) }) == 0 {
+		return nil
+	}
+	l.Emit(1)
+	return lexSplicedWords
+}
+
+func TestSpliceLines(t *testing.T) {
+	input := "ab\\\ncd e\\\r\n\\\nf g\\\n"
+	items := Collect(New(lexSplicedWords, input, WithTransform(SpliceLines)))
+	want := []struct {
+		value string
+		pos   int
+	}{
+		{"abcd", 0},
+		{"ef", 7},
+		{"g", 15},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d: %v", len(items), len(want), items)
+	}
+	for i, w := range want {
+		if items[i].Value != w.value || items[i].Pos != w.pos {
+			t.Errorf("item %d: %q at %d, want %q at %d", i, items[i].Value, items[i].Pos, w.value, w.pos)
+		}
+	}
+}
+
+func TestSpliceLinesBackup(t *testing.T) {
+	l := New(lexSplicedWords, "a\\\nb", WithTransform(SpliceLines))
+	l.Advance()
+	if c, n := l.Advance(); c != 'b' || n != 3 {
+		t.Fatalf("Advance returned %q %d", c, n)
+	}
+	l.Backup()
+	if l.Pos() != 1 {
+		t.Errorf("position %d after Backup", l.Pos())
+	}
+	if c, _ := l.Peek(); c != 'b' {
+		t.Errorf("Peek returned %q", c)
+	}
+	if cur := l.Current(); cur != "a" {
+		t.Errorf("current lexeme %q", cur)
+	}
+}
+
+func TestTransformUnchanged(t *testing.T) {
+	l := New(lexSplicedWords, "abc", WithTransform(SpliceLines))
+	l.AcceptRun("abc")
+	if cur := l.Current(); cur != "abc" {
+		t.Errorf("current lexeme %q", cur)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"io"
+	"regexp"
+	"unicode"
+)
+
+// TypedStateFn is a StateFn for a TypedLexer.
+type TypedStateFn[T ~uint16] func(*TypedLexer[T]) TypedStateFn[T]
+
+// TypedLexer is a Lexer whose items have types of a named kind T rather than
+// ItemType, so that a language's token enumeration can be used directly
+// without conversions.  The scanner methods of Lexer, which do not involve
+// item types or state functions, are available directly, and Emit, Errorf,
+// Next and PeekItem are versions using T.  The methods of Lexer that take or
+// return ItemType, items or a StateFn are reached only through Untyped.  The
+// special types convert to T, for example T(ItemEOF).
+//
+// The non-generic Lexer is unchanged and is equivalent to a TypedLexer with
+// T = ItemType.
+type TypedLexer[T ~uint16] struct {
+	scanner
+	l     *Lexer
+	start TypedStateFn[T]
+	state TypedStateFn[T]
+	step  StateFn
+}
+
+// scanner is the part of the Lexer API that is independent of item types,
+// promoted by TypedLexer.
+type scanner interface {
+	Accept(valid string) bool
+	AcceptByte(valid string) bool
+	AcceptBytes(valid string) int
+	AcceptCount(valid string, n int) bool
+	AcceptFold(valid string) bool
+	AcceptFunc(fn func(rune) bool) bool
+	AcceptGlob(pattern string) bool
+	AcceptRange(tab *unicode.RangeTable) bool
+	AcceptRangeFold(tab *unicode.RangeTable) bool
+	AcceptRegexp(re *regexp.Regexp) bool
+	AcceptRun(valid string) int
+	AcceptRunFunc(fn func(rune) bool) int
+	AcceptRunMax(valid string, max int) int
+	AcceptRunNotAny(stops string) int
+	AcceptRunNotFunc(stop func(rune) bool) int
+	AcceptRunRange(tab *unicode.RangeTable) int
+	AcceptRunSet(s *RuneSet) int
+	AcceptSeq(ms ...Matcher) bool
+	AcceptSet(s *RuneSet) bool
+	AcceptString(s string) bool
+	Advance() (rune, int)
+	AdvanceByte() (byte, bool)
+	AdvanceTo(b byte) bool
+	Backup()
+	Classes() CharClasses
+	Commit(n int)
+	Current() string
+	Data() interface{}
+	Errors() []error
+	Expect(desc string)
+	ExpectKeyword(k *Keywords, kind string) bool
+	ExpectedSoFar() []string
+	Ignore()
+	IgnoreRun(valid string) int
+	IgnoreUntil(stop string) int
+	IgnoreWhile(fn func(rune) bool) int
+	Input() string
+	InputDepth() int
+	IsDigit(r rune) bool
+	IsLetter(r rune) bool
+	IsSpace(r rune) bool
+	Last() (rune, int)
+	LazyWarnf(format string, vs ...interface{})
+	Mode() string
+	ModeDepth() int
+	Peek() (rune, int)
+	PeekByte() (byte, bool)
+	PopInput() bool
+	Pos() int
+	Position(offset int) Position
+	PushInput(name, text string)
+	Restore(s State)
+	Save() State
+	ScanBlockComment(open, close string, nested bool) (bool, error)
+	ScanFloat() (NumberKind, error)
+	ScanGoNumber() (NumberKind, error)
+	ScanHeredoc(intro string) (*Heredoc, error)
+	ScanQuoted(spec QuoteSpec) (bool, error)
+	ScanRaw(open, close string) (bool, error)
+	SetData(v interface{})
+	SetMeta(key, value string)
+	SetPos(offset int)
+	SkipSpace() int
+	SkipUntilByte(b byte) bool
+	Source() *Source
+	Start() int
+	TailReader() io.Reader
+	WarnIdentifier() bool
+	Warnf(format string, vs ...interface{})
+	Whitespace() *RuneSet
+}
+
+// TypedItem is an item with a type of kind T.
+type TypedItem[T ~uint16] struct {
+	Type T
+	*Item
+}
+
+// NewTyped creates a new TypedLexer.  Must be given a non-nil state.
+func NewTyped[T ~uint16](start TypedStateFn[T], input string, opts ...Option) *TypedLexer[T] {
+	if start == nil {
+		panic("nil start state")
+	}
+	l := &TypedLexer[T]{start: start, state: start}
+	l.step = l.run
+	l.l = New(l.step, input, opts...)
+	l.scanner = l.l
+	return l
+}
+
+// Untyped returns the Lexer underlying l, for the methods that use ItemType.
+func (l *TypedLexer[T]) Untyped() *Lexer {
+	return l.l
+}
+
+// Reset discards the state of l so that it lexes input from its start state,
+// as with Lexer.Reset.
+func (l *TypedLexer[T]) Reset(input string) {
+	l.state = l.start
+	l.l.Reset(input)
+}
+
+// run adapts the current TypedStateFn to the underlying Lexer.
+func (l *TypedLexer[T]) run(*Lexer) StateFn {
+	l.state = l.state(l)
+	if l.state == nil {
+		return nil
+	}
+	return l.step
+}
+
+// Emit the current value as an item with the specified type.
+func (l *TypedLexer[T]) Emit(t T) {
+	l.l.Emit(ItemType(t))
+}
+
+// Errorf causes an error item to be emitted, as with Lexer.Errorf.
+func (l *TypedLexer[T]) Errorf(format string, vs ...interface{}) TypedStateFn[T] {
+	l.l.Errorf(format, vs...)
+	return nil
+}
+
+// Next returns the next item from the input, as with Lexer.Next.
+func (l *TypedLexer[T]) Next() TypedItem[T] {
+	return typed[T](l.l.Next())
+}
+
+// PeekItem returns the next item without consuming it, as with
+// Lexer.PeekItem.
+func (l *TypedLexer[T]) PeekItem() TypedItem[T] {
+	return typed[T](l.l.PeekItem())
+}
+
+func typed[T ~uint16](i *Item) TypedItem[T] {
+	return TypedItem[T]{T(i.Type), i}
+}
@@ -0,0 +1,54 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+type testToken uint16
+
+const (
+	tokWord testToken = iota
+	tokSpace
+	tokEOF = testToken(ItemEOF)
+)
+
+func TestTypedLexer(t *testing.T) {
+	var start TypedStateFn[testToken]
+	start = func(l *TypedLexer[testToken]) TypedStateFn[testToken] {
+		switch {
+		case l.AcceptRun("ab") > 0:
+			l.Emit(tokWord)
+		case l.AcceptRun(" ") > 0:
+			l.Emit(tokSpace)
+		default:
+			return nil
+		}
+		return start
+	}
+	l := NewTyped(start, "ab ba")
+	var types []testToken
+	for {
+		item := l.Next()
+		if item.Type == tokEOF {
+			break
+		}
+		types = append(types, item.Type)
+	}
+	if len(types) != 3 || types[0] != tokWord || types[1] != tokSpace || types[2] != tokWord {
+		t.Errorf("unexpected types %v", types)
+	}
+	l.Reset("ba")
+	if item := l.Next(); item.Type != tokWord || item.Value != "ba" {
+		t.Errorf("after Reset: %v %q", item.Type, item.Value)
+	}
+	if _, ok := interface{}(l).(interface{ EmitMarker(ItemType) }); ok {
+		t.Errorf("untyped Lexer methods are promoted")
+	}
+	if u := l.Untyped(); u.Input() != "ba" || u.TypeName(ItemType(tokWord)) != "ItemType(0)" {
+		t.Errorf("untyped lexer for %q", u.Input())
+	}
+}
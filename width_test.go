@@ -0,0 +1,47 @@
+// Copyright 2012, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lexer
+
+import (
+	"testing"
+)
+
+func TestRuneWidth(t *testing.T) {
+	for _, test := range []struct {
+		r     rune
+		width int
+	}{
+		{'a', 1},
+		{'\t', 1},
+		{'\n', 0},
+		{'é', 1},
+		{0x0301, 0}, // combining acute accent
+		{0x200d, 0}, // zero width joiner
+		{'日', 2},
+		{'한', 2},
+		{0xff21, 2}, // fullwidth A
+		{0xff61, 1}, // halfwidth ideographic full stop
+		{0x1f600, 2},
+		{0x20000, 2},
+	} {
+		if w := RuneWidth(test.r); w != test.width {
+			t.Errorf("RuneWidth(%U) = %d, want %d", test.r, w, test.width)
+		}
+	}
+	if w := DisplayWidth("é日本x"); w != 6 {
+		t.Errorf("DisplayWidth = %d", w)
+	}
+}
+
+func TestDisplayColumns(t *testing.T) {
+	const input = "x = \"日本\" + y\n"
+	off := len("x = \"日本\" + ")
+	if col := New(lexRunes, input).Position(off).Column; col != 12 {
+		t.Errorf("rune column %d", col)
+	}
+	if col := New(lexRunes, input, WithDisplayColumns()).Position(off).Column; col != 14 {
+		t.Errorf("display column %d", col)
+	}
+}